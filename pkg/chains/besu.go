@@ -2,7 +2,9 @@ package chains
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
@@ -10,18 +12,52 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
+// ConsensusType is a BFT consensus protocol of Besu
+type ConsensusType string
+
+const (
+	ConsensusIBFT2 ConsensusType = "ibft2"
+	ConsensusQBFT  ConsensusType = "qbft"
+	// ConsensusClique is the PoA consensus of geth. Its extra data isn't RLP encoded.
+	ConsensusClique ConsensusType = "clique"
+)
+
+// ParseConsensusType parses the name of a consensus type, e.g. a configuration value
+func ParseConsensusType(s string) (ConsensusType, error) {
+	switch ct := ConsensusType(strings.ToLower(s)); ct {
+	case ConsensusIBFT2, ConsensusQBFT, ConsensusClique:
+		return ct, nil
+	default:
+		return "", fmt.Errorf("unknown consensus type: %v", s)
+	}
+}
+
 type ParsedHeader struct {
 	Base *gethtypes.Header
+	// Consensus determines the encoding of the extra data
+	Consensus ConsensusType
 
 	Vanity     [32]byte
 	Validators []common.Address
-	Vote       interface{}
-	Round      [4]byte
-	Seals      [][]byte
+	// Vote is nil if the proposer doesn't vote
+	Vote *Vote
+	// Round is a big-endian round number. IBFT2 encodes it as 4 bytes, but QBFT encodes it as a scalar.
+	Round [4]byte
+	Seals [][]byte
 }
 
+// ParseHeader parses the extra data of an IBFT2 header: `[vanity, validators, vote, round, seals]`
+// Use ParseHeaderByConsensus for the other consensus types.
 func ParseHeader(header *gethtypes.Header) (*ParsedHeader, error) {
-	parsed := ParsedHeader{Base: header}
+	return ParseHeaderByConsensus(header, ConsensusIBFT2)
+}
+
+// ParseHeaderByConsensus parses the extra data of the header with the encoding of the consensus type.
+func ParseHeaderByConsensus(header *gethtypes.Header, consensus ConsensusType) (*ParsedHeader, error) {
+	if consensus == ConsensusClique {
+		return parseCliqueHeader(header)
+	}
+	parsed := ParsedHeader{Base: header, Consensus: consensus}
 
 	r := bytes.NewReader(header.Extra)
 	stream := rlp.NewStream(r, uint64(len(header.Extra)))
@@ -34,12 +70,27 @@ func ParseHeader(header *gethtypes.Header) (*ParsedHeader, error) {
 	if err := stream.Decode(&parsed.Validators); err != nil {
 		return nil, err
 	}
-	if err := stream.Decode(&parsed.Vote); err != nil {
+	rawVote, err := stream.Raw()
+	if err != nil {
 		return nil, err
 	}
-	if err := stream.Decode(&parsed.Round); err != nil {
+	if parsed.Vote, err = decodeVote(rawVote); err != nil {
 		return nil, err
 	}
+	switch consensus {
+	case ConsensusIBFT2:
+		if err := stream.Decode(&parsed.Round); err != nil {
+			return nil, err
+		}
+	case ConsensusQBFT:
+		var round uint32
+		if err := stream.Decode(&round); err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint32(parsed.Round[:], round)
+	default:
+		return nil, fmt.Errorf("unknown consensus type: %v", consensus)
+	}
 	if err := stream.Decode(&parsed.Seals); err != nil {
 		return nil, err
 	}
@@ -50,36 +101,93 @@ func ParseHeader(header *gethtypes.Header) (*ParsedHeader, error) {
 	return &parsed, nil
 }
 
+// DetectConsensusType guesses the consensus type from the encoding of the round in the extra data.
+// IBFT2 always encodes the round as 4 bytes, but QBFT encodes it as a scalar that is shorter than 4 bytes unless the round exceeds 2^24.
+// The extra data that isn't RLP encoded is detected as Clique if it has the layout of Clique.
+// NOTE: this is only a fallback for the callers that don't know the consensus type. A QBFT header whose round is 2^24 or more is detected as IBFT2,
+// so the consensus type should be given explicitly if it's known, e.g. to ParseHeaderByConsensus, NewMisbehaviourDetector and ScanValidatorTransitions.
+func DetectConsensusType(header *gethtypes.Header) (ConsensusType, error) {
+	var extra []rlp.RawValue
+	if err := rlp.DecodeBytes(header.Extra, &extra); err != nil {
+		if isCliqueExtra(header.Extra) {
+			return ConsensusClique, nil
+		}
+		return "", err
+	} else if len(extra) != 5 {
+		return "", fmt.Errorf("unexpected number of items in the extra data: %v", len(extra))
+	}
+	_, content, _, err := rlp.Split(extra[3])
+	if err != nil {
+		return "", err
+	}
+	if len(content) == 4 {
+		return ConsensusIBFT2, nil
+	}
+	return ConsensusQBFT, nil
+}
+
+// RoundNumber returns the round number of the header
+func (h ParsedHeader) RoundNumber() uint32 {
+	return binary.BigEndian.Uint32(h.Round[:])
+}
+
+// GetSealingHeaderBytes returns the RLP encoded header whose hash is signed by the commit seals.
+// IBFT2 excludes the seals from the extra data, but QBFT replaces them with an empty list. Clique excludes the seal suffix.
 func (h ParsedHeader) GetSealingHeaderBytes() ([]byte, error) {
-	newHeader := *h.Base
-	extra, err := rlp.EncodeToBytes([]interface{}{
-		h.Vanity, h.Validators, h.Vote, h.Round,
-	})
+	if h.Consensus == ConsensusClique {
+		return h.cliqueSealingHeaderBytes()
+	}
+	vote, err := encodeVote(h.Vote, h.Consensus)
 	if err != nil {
 		return nil, err
 	}
-	newHeader.Extra = extra
-	return rlp.EncodeToBytes(&newHeader)
+	var items []interface{}
+	switch h.Consensus {
+	case ConsensusQBFT:
+		items = []interface{}{h.Vanity, h.Validators, vote, h.RoundNumber(), []interface{}{}}
+	default:
+		items = []interface{}{h.Vanity, h.Validators, vote, h.Round}
+	}
+	return h.encodeWithExtra(items)
 }
 
+// GetChainHeaderBytes returns the RLP encoded header whose hash is the block hash.
+// IBFT2 excludes the round and the seals from the extra data, but QBFT replaces them with 0 and an empty list. Clique hashes the whole header.
 func (h ParsedHeader) GetChainHeaderBytes() ([]byte, error) {
-	newHeader := *h.Base
-	extra, err := rlp.EncodeToBytes([]interface{}{
-		h.Vanity, h.Validators, h.Vote,
-	})
+	if h.Consensus == ConsensusClique {
+		return EncodeBesuHeader(h.Base, h.Base.Extra)
+	}
+	vote, err := encodeVote(h.Vote, h.Consensus)
+	if err != nil {
+		return nil, err
+	}
+	var items []interface{}
+	switch h.Consensus {
+	case ConsensusQBFT:
+		items = []interface{}{h.Vanity, h.Validators, vote, uint32(0), []interface{}{}}
+	default:
+		items = []interface{}{h.Vanity, h.Validators, vote}
+	}
+	return h.encodeWithExtra(items)
+}
+
+func (h ParsedHeader) encodeWithExtra(items []interface{}) ([]byte, error) {
+	extra, err := rlp.EncodeToBytes(items)
 	if err != nil {
 		return nil, err
 	}
-	newHeader.Extra = extra
-	return rlp.EncodeToBytes(&newHeader)
+	return EncodeBesuHeader(h.Base, extra)
 }
 
 func (h ParsedHeader) ValidateAndGetCommitSeals() ([][]byte, error) {
+	if h.Consensus == ConsensusClique {
+		return nil, fmt.Errorf("clique header has no commit seals: use CliqueTracker to verify the signer")
+	}
 	header, err := h.GetSealingHeaderBytes()
 	if err != nil {
 		return nil, err
 	}
-	vals, err := RecoverCommitterAddressesVals(crypto.Keccak256(header), h.Seals)
+	vals, err := DefaultSealVerifier.RecoverCommitterAddressesVals(crypto.Keccak256(header), h.Seals)
 	if err != nil {
 		return nil, err
 	}
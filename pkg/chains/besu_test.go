@@ -0,0 +1,132 @@
+package chains
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHeaderByConsensus(t *testing.T) {
+	var keys []*ecdsa.PrivateKey
+	var validators []common.Address
+	for i := 0; i < 4; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keys = append(keys, key)
+		validators = append(validators, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	var vanity [32]byte
+
+	for _, c := range []struct {
+		consensus ConsensusType
+		// extra returns the extra data with the round and the seals
+		extra func(round uint32, seals [][]byte) []interface{}
+	}{
+		{ConsensusIBFT2, func(round uint32, seals [][]byte) []interface{} {
+			r := [4]byte{byte(round >> 24), byte(round >> 16), byte(round >> 8), byte(round)}
+			items := []interface{}{vanity, validators, []byte{}, r}
+			if seals != nil {
+				items = append(items, seals)
+			}
+			return items
+		}},
+		{ConsensusQBFT, func(round uint32, seals [][]byte) []interface{} {
+			if seals == nil {
+				seals = [][]byte{}
+			}
+			return []interface{}{vanity, validators, []interface{}{}, round, seals}
+		}},
+	} {
+		t.Run(string(c.consensus), func(t *testing.T) {
+			base := &gethtypes.Header{Number: big.NewInt(10), Difficulty: big.NewInt(1), GasLimit: 1000000}
+			withExtra := func(items []interface{}) *gethtypes.Header {
+				h := gethtypes.CopyHeader(base)
+				extra, err := rlp.EncodeToBytes(items)
+				require.NoError(t, err)
+				h.Extra = extra
+				return h
+			}
+
+			// sign the sealing header by 3 of 4 validators
+			sealingHash := withExtra(c.extra(1, nil)).Hash()
+			var seals [][]byte
+			for _, key := range keys[:3] {
+				seal, err := crypto.Sign(sealingHash.Bytes(), key)
+				require.NoError(t, err)
+				seals = append(seals, seal)
+			}
+			header := withExtra(c.extra(1, seals))
+
+			consensus, err := DetectConsensusType(header)
+			require.NoError(t, err)
+			require.Equal(t, c.consensus, consensus)
+
+			parsed, err := ParseHeaderByConsensus(header, consensus)
+			require.NoError(t, err)
+			require.Equal(t, uint32(1), parsed.RoundNumber())
+			require.Equal(t, validators, parsed.Validators)
+
+			bz, err := parsed.GetSealingHeaderBytes()
+			require.NoError(t, err)
+			require.Equal(t, sealingHash, crypto.Keccak256Hash(bz))
+
+			commitSeals, err := parsed.ValidateAndGetCommitSeals()
+			require.NoError(t, err)
+			require.Len(t, commitSeals, 4)
+			require.Nil(t, commitSeals[3])
+
+			// the chain header excludes the round and the seals
+			bz, err = parsed.GetChainHeaderBytes()
+			require.NoError(t, err)
+			var chainHash common.Hash
+			if consensus == ConsensusQBFT {
+				chainHash = withExtra(c.extra(0, nil)).Hash()
+			} else {
+				chainHash = withExtra([]interface{}{vanity, validators, []byte{}}).Hash()
+			}
+			require.Equal(t, chainHash, crypto.Keccak256Hash(bz))
+
+			// insufficient seals
+			parsed.Seals = seals[:2]
+			_, err = parsed.ValidateAndGetCommitSeals()
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestConsensusTypeOverride(t *testing.T) {
+	var keys []*ecdsa.PrivateKey
+	for i := 0; i < 4; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	built, err := BuildHeader(
+		&gethtypes.Header{Number: big.NewInt(10), Difficulty: big.NewInt(1), GasLimit: 1000000},
+		keys, 1<<24, nil, WithConsensusType(ConsensusQBFT),
+	)
+	require.NoError(t, err)
+
+	// the heuristic misdetects a QBFT round that is encoded as 4 bytes
+	detected, err := DetectConsensusType(built.Base)
+	require.NoError(t, err)
+	require.Equal(t, ConsensusIBFT2, detected)
+
+	// the explicit consensus type parses the header correctly
+	consensus, err := ParseConsensusType("QBFT")
+	require.NoError(t, err)
+	parsed, err := ParseHeaderByConsensus(built.Base, consensus)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1<<24), parsed.RoundNumber())
+	_, err = parsed.ValidateAndGetCommitSeals()
+	require.NoError(t, err)
+
+	_, err = ParseConsensusType("pow")
+	require.Error(t, err)
+}
@@ -0,0 +1,136 @@
+package chains
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+type HeaderOption func(*headerOption)
+
+type headerOption struct {
+	consensus ConsensusType
+	vanity    [32]byte
+	// missingSeals are the indexes of the validators that don't sign the header
+	missingSeals map[int]struct{}
+	// extraSigners sign the header in addition to the validators
+	extraSigners []*ecdsa.PrivateKey
+}
+
+func DefaultHeaderOption() *headerOption {
+	return &headerOption{
+		consensus:    ConsensusIBFT2,
+		missingSeals: make(map[int]struct{}),
+	}
+}
+
+func WithConsensusType(consensus ConsensusType) HeaderOption {
+	return func(opt *headerOption) {
+		opt.consensus = consensus
+	}
+}
+
+func WithVanity(vanity [32]byte) HeaderOption {
+	return func(opt *headerOption) {
+		opt.vanity = vanity
+	}
+}
+
+// WithMissingSeals omits the seals of the validators at the indexes
+func WithMissingSeals(indexes ...int) HeaderOption {
+	return func(opt *headerOption) {
+		for _, i := range indexes {
+			opt.missingSeals[i] = struct{}{}
+		}
+	}
+}
+
+// WithExtraSigners appends the seals of the keys after the seals of the validators.
+// The keys can be non-validators or validators to duplicate their seals.
+func WithExtraSigners(keys ...*ecdsa.PrivateKey) HeaderOption {
+	return func(opt *headerOption) {
+		opt.extraSigners = append(opt.extraSigners, keys...)
+	}
+}
+
+// BuildHeader builds a header sealed by the validators. It is the inverse of ParseHeaderByConsensus.
+// The validator set of the header consists of the addresses of `validators` in the given order, and each validator signs the header in the order.
+// NOTE: Besu sorts the validators by address, so callers should sort the keys to mimic a real network.
+func BuildHeader(template *gethtypes.Header, validators []*ecdsa.PrivateKey, round uint32, vote *Vote, opts ...HeaderOption) (*ParsedHeader, error) {
+	opt := DefaultHeaderOption()
+	for _, o := range opts {
+		o(opt)
+	}
+	parsed := ParsedHeader{
+		Base:      gethtypes.CopyHeader(template),
+		Consensus: opt.consensus,
+		Vanity:    opt.vanity,
+		Vote:      vote,
+	}
+	binary.BigEndian.PutUint32(parsed.Round[:], round)
+	parsed.Validators = SignerAddresses(validators)
+
+	sealingHeader, err := parsed.GetSealingHeaderBytes()
+	if err != nil {
+		return nil, err
+	}
+	hash := crypto.Keccak256(sealingHeader)
+	parsed.Seals = [][]byte{}
+	for i, key := range validators {
+		if _, ok := opt.missingSeals[i]; ok {
+			continue
+		}
+		seal, err := crypto.Sign(hash, key)
+		if err != nil {
+			return nil, err
+		}
+		parsed.Seals = append(parsed.Seals, seal)
+	}
+	for _, key := range opt.extraSigners {
+		seal, err := crypto.Sign(hash, key)
+		if err != nil {
+			return nil, err
+		}
+		parsed.Seals = append(parsed.Seals, seal)
+	}
+
+	parsed.Base.Extra, err = parsed.EncodeExtra()
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// EncodeExtra returns the extra data including the seals: `[vanity, validators, vote, round, seals]`
+func (h ParsedHeader) EncodeExtra() ([]byte, error) {
+	vote, err := encodeVote(h.Vote, h.Consensus)
+	if err != nil {
+		return nil, err
+	}
+	seals := h.Seals
+	if seals == nil {
+		seals = [][]byte{}
+	}
+	switch h.Consensus {
+	case ConsensusIBFT2:
+		return rlp.EncodeToBytes([]interface{}{h.Vanity, h.Validators, vote, h.Round, seals})
+	case ConsensusQBFT:
+		return rlp.EncodeToBytes([]interface{}{h.Vanity, h.Validators, vote, h.RoundNumber(), seals})
+	default:
+		return nil, fmt.Errorf("unknown consensus type: %v", h.Consensus)
+	}
+}
+
+// SignerAddresses returns the addresses of the keys
+func SignerAddresses(keys []*ecdsa.PrivateKey) []common.Address {
+	var addrs []common.Address
+	for _, key := range keys {
+		addrs = append(addrs, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	return addrs
+}
@@ -0,0 +1,59 @@
+package chains
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildHeader(t *testing.T) {
+	var keys []*ecdsa.PrivateKey
+	for i := 0; i < 4; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	outsider, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	template := &gethtypes.Header{Number: big.NewInt(10), Difficulty: big.NewInt(1), GasLimit: 1000000, Time: 100}
+	vote := &Vote{Recipient: common.HexToAddress("0x01"), Type: VoteAdd}
+
+	for _, consensus := range []ConsensusType{ConsensusIBFT2, ConsensusQBFT} {
+		t.Run(string(consensus), func(t *testing.T) {
+			// 1. The built header can be parsed and validated
+			built, err := BuildHeader(template, keys, 2, vote, WithConsensusType(consensus))
+			require.NoError(t, err)
+			detected, err := DetectConsensusType(built.Base)
+			require.NoError(t, err)
+			require.Equal(t, consensus, detected)
+			parsed, err := ParseHeaderByConsensus(built.Base, consensus)
+			require.NoError(t, err)
+			require.Equal(t, built, parsed)
+			require.Equal(t, uint32(2), parsed.RoundNumber())
+			require.Equal(t, vote, parsed.Vote)
+			seals, err := parsed.ValidateAndGetCommitSeals()
+			require.NoError(t, err)
+			require.Len(t, seals, len(keys))
+
+			// 2. The threshold is not satisfied with 2 of 4 seals even if non-validators sign
+			built, err = BuildHeader(template, keys, 0, nil, WithConsensusType(consensus), WithMissingSeals(0, 1), WithExtraSigners(outsider))
+			require.NoError(t, err)
+			require.Len(t, built.Seals, 3)
+			require.Nil(t, built.Vote)
+			_, err = built.ValidateAndGetCommitSeals()
+			require.Error(t, err)
+
+			// 3. The threshold is satisfied with 3 of 4 seals
+			built, err = BuildHeader(template, keys, 0, nil, WithConsensusType(consensus), WithMissingSeals(3))
+			require.NoError(t, err)
+			seals, err = built.ValidateAndGetCommitSeals()
+			require.NoError(t, err)
+			require.Nil(t, seals[3])
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package chains
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// cliqueVanityLength is the length of the vanity prefix of the extra data
+	cliqueVanityLength = 32
+	// cliqueSealLength is the length of the signature suffix of the extra data
+	cliqueSealLength = crypto.SignatureLength
+
+	// difficulties of the blocks signed by the in-turn and out-of-turn signers
+	cliqueDiffInTurn = 2
+	cliqueDiffNoTurn = 1
+
+	cliqueDefaultEpoch = 30000
+)
+
+var (
+	// nonces of the block to vote to add or drop the coinbase
+	cliqueNonceAuthVote = gethtypes.BlockNonce{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	cliqueNonceDropVote = gethtypes.BlockNonce{}
+)
+
+// parseCliqueHeader parses the extra data of a Clique header: `vanity(32 bytes) || signers(20 bytes each, only at checkpoints) || seal(65 bytes)`.
+// The seal is stored as the only element of Seals and the vote is taken from the coinbase and the nonce.
+func parseCliqueHeader(header *gethtypes.Header) (*ParsedHeader, error) {
+	parsed := ParsedHeader{Base: header, Consensus: ConsensusClique}
+	extra := header.Extra
+	if len(extra) < cliqueVanityLength+cliqueSealLength {
+		return nil, fmt.Errorf("extra data is too short: %v", len(extra))
+	}
+	signers := extra[cliqueVanityLength : len(extra)-cliqueSealLength]
+	if len(signers)%common.AddressLength != 0 {
+		return nil, fmt.Errorf("invalid length of the checkpoint signers: %v", len(signers))
+	}
+	copy(parsed.Vanity[:], extra[:cliqueVanityLength])
+	for i := 0; i < len(signers); i += common.AddressLength {
+		parsed.Validators = append(parsed.Validators, common.BytesToAddress(signers[i:i+common.AddressLength]))
+	}
+	parsed.Seals = [][]byte{common.CopyBytes(extra[len(extra)-cliqueSealLength:])}
+
+	switch header.Nonce {
+	case cliqueNonceAuthVote:
+		parsed.Vote = &Vote{Recipient: header.Coinbase, Type: VoteAdd}
+	case cliqueNonceDropVote:
+		if header.Coinbase != (common.Address{}) {
+			parsed.Vote = &Vote{Recipient: header.Coinbase, Type: VoteDrop}
+		}
+	default:
+		return nil, fmt.Errorf("invalid vote nonce: %x", header.Nonce)
+	}
+	return &parsed, nil
+}
+
+// isCliqueExtra returns true if the extra data has the layout of Clique
+func isCliqueExtra(extra []byte) bool {
+	n := len(extra) - cliqueVanityLength - cliqueSealLength
+	return n >= 0 && n%common.AddressLength == 0
+}
+
+// cliqueSealingHeaderBytes returns the RLP encoded header without the seal
+func (h ParsedHeader) cliqueSealingHeaderBytes() ([]byte, error) {
+	extra := h.Base.Extra
+	if len(extra) < cliqueSealLength {
+		return nil, fmt.Errorf("extra data is too short: %v", len(extra))
+	}
+	return EncodeBesuHeader(h.Base, extra[:len(extra)-cliqueSealLength])
+}
+
+// CliqueSigner recovers the signer of the Clique header from the seal
+func (h ParsedHeader) CliqueSigner() (common.Address, error) {
+	if h.Consensus != ConsensusClique {
+		return common.Address{}, fmt.Errorf("not a clique header: %v", h.Consensus)
+	} else if len(h.Seals) != 1 {
+		return common.Address{}, fmt.Errorf("clique header must have exactly one seal: %v", len(h.Seals))
+	}
+	bz, err := h.GetSealingHeaderBytes()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return ECRecoverAddress(crypto.Keccak256(bz), h.Seals[0])
+}
+
+// CliqueTracker tracks the signer set of a Clique chain by applying consecutive headers from a checkpoint.
+// It follows the voting rules of go-ethereum's Clique snapshot.
+type CliqueTracker struct {
+	epoch   uint64
+	height  uint64
+	signers map[common.Address]struct{}
+	// recents is the signers of the recent blocks to reject a signer signing too often
+	recents map[uint64]common.Address
+	// votes is the latest vote of each signer for each candidate
+	votes map[common.Address]map[common.Address]VoteType
+	// transitions is the heights where the signer set changed
+	transitions []uint64
+}
+
+// NewCliqueTracker returns a CliqueTracker that starts from the checkpoint header. The epoch is 30000 blocks by default in geth.
+func NewCliqueTracker(epoch uint64, checkpoint *ParsedHeader) (*CliqueTracker, error) {
+	if epoch == 0 {
+		epoch = cliqueDefaultEpoch
+	}
+	if checkpoint.Consensus != ConsensusClique {
+		return nil, fmt.Errorf("not a clique header: %v", checkpoint.Consensus)
+	}
+	height := checkpoint.Base.Number.Uint64()
+	if height%epoch != 0 {
+		return nil, fmt.Errorf("not a checkpoint: height=%v epoch=%v", height, epoch)
+	} else if len(checkpoint.Validators) == 0 {
+		return nil, fmt.Errorf("checkpoint has no signers: height=%v", height)
+	}
+	t := &CliqueTracker{
+		epoch:   epoch,
+		height:  height,
+		signers: make(map[common.Address]struct{}),
+		recents: make(map[uint64]common.Address),
+		votes:   make(map[common.Address]map[common.Address]VoteType),
+	}
+	for _, signer := range checkpoint.Validators {
+		t.signers[signer] = struct{}{}
+	}
+	return t, nil
+}
+
+// Height returns the height of the last applied header
+func (t *CliqueTracker) Height() uint64 {
+	return t.height
+}
+
+// Signers returns the authorized signers in ascending order
+func (t *CliqueTracker) Signers() []common.Address {
+	signers := make([]common.Address, 0, len(t.signers))
+	for signer := range t.signers {
+		signers = append(signers, signer)
+	}
+	sort.Slice(signers, func(i, j int) bool {
+		return bytes.Compare(signers[i][:], signers[j][:]) < 0
+	})
+	return signers
+}
+
+// Transitions returns the heights where the signer set changed
+func (t *CliqueTracker) Transitions() []uint64 {
+	return append([]uint64{}, t.transitions...)
+}
+
+// Apply verifies the seal of the next header and applies its vote. It returns the signer of the header.
+func (t *CliqueTracker) Apply(header *ParsedHeader) (common.Address, error) {
+	height := header.Base.Number.Uint64()
+	if height != t.height+1 {
+		return common.Address{}, fmt.Errorf("non-contiguous header: expected=%v actual=%v", t.height+1, height)
+	}
+	signer, err := header.CliqueSigner()
+	if err != nil {
+		return common.Address{}, err
+	}
+	if _, ok := t.signers[signer]; !ok {
+		return common.Address{}, fmt.Errorf("unauthorized signer: height=%v signer=%v", height, signer)
+	}
+	limit := uint64(len(t.signers)/2 + 1)
+	if height >= limit {
+		delete(t.recents, height-limit)
+	}
+	for h, recent := range t.recents {
+		if recent == signer {
+			return common.Address{}, fmt.Errorf("signer signed recently: height=%v signer=%v last=%v", height, signer, h)
+		}
+	}
+	if expected := t.difficulty(height, signer); header.Base.Difficulty == nil || header.Base.Difficulty.Uint64() != expected {
+		return common.Address{}, fmt.Errorf("invalid difficulty: height=%v expected=%v actual=%v", height, expected, header.Base.Difficulty)
+	}
+
+	if height%t.epoch == 0 {
+		if header.Vote != nil {
+			return common.Address{}, fmt.Errorf("checkpoint must not vote: height=%v", height)
+		}
+		if !sameSigners(header.Validators, t.Signers()) {
+			return common.Address{}, fmt.Errorf("checkpoint signers mismatch: height=%v header=%v expected=%v", height, header.Validators, t.Signers())
+		}
+		t.votes = make(map[common.Address]map[common.Address]VoteType)
+	} else if len(header.Validators) != 0 {
+		return common.Address{}, fmt.Errorf("non-checkpoint header must not have signers: height=%v", height)
+	}
+	t.recents[height] = signer
+	t.height = height
+
+	if header.Vote != nil {
+		t.applyVote(height, signer, header.Vote)
+	}
+	return signer, nil
+}
+
+func (t *CliqueTracker) difficulty(height uint64, signer common.Address) uint64 {
+	signers := t.Signers()
+	if signers[height%uint64(len(signers))] == signer {
+		return cliqueDiffInTurn
+	}
+	return cliqueDiffNoTurn
+}
+
+func (t *CliqueTracker) applyVote(height uint64, signer common.Address, vote *Vote) {
+	candidate := vote.Recipient
+	// the previous vote of the signer for the candidate is replaced
+	delete(t.votes[candidate], signer)
+	_, authorized := t.signers[candidate]
+	if (vote.Type == VoteAdd) == authorized {
+		return
+	}
+	if t.votes[candidate] == nil {
+		t.votes[candidate] = make(map[common.Address]VoteType)
+	}
+	t.votes[candidate][signer] = vote.Type
+	if len(t.votes[candidate]) <= len(t.signers)/2 {
+		return
+	}
+
+	if vote.Type == VoteAdd {
+		t.signers[candidate] = struct{}{}
+	} else {
+		delete(t.signers, candidate)
+		// the signer set shrank, so the oldest recent signer can sign again
+		if limit := uint64(len(t.signers)/2 + 1); height >= limit {
+			delete(t.recents, height-limit)
+		}
+		for _, votes := range t.votes {
+			delete(votes, candidate)
+		}
+	}
+	delete(t.votes, candidate)
+	t.transitions = append(t.transitions, height)
+}
+
+func sameSigners(a, b []common.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
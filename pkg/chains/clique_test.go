@@ -0,0 +1,126 @@
+package chains
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCliqueTracker(t *testing.T) {
+	keys := make(map[common.Address]*ecdsa.PrivateKey)
+	var signers []common.Address
+	for i := 0; i < 4; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		keys[addr] = key
+		signers = append(signers, addr)
+	}
+	sort.Slice(signers, func(i, j int) bool { return bytes.Compare(signers[i][:], signers[j][:]) < 0 })
+	// the last signer is added by votes
+	initial, candidate := signers[:3], signers[3]
+	const epoch = 10
+
+	// 1. The checkpoint header is parsed and its signer is recovered
+	checkpoint := buildCliqueHeader(t, 0, initial, nil, cliqueDiffInTurn, keys[initial[0]])
+	detected, err := DetectConsensusType(checkpoint.Base)
+	require.NoError(t, err)
+	require.Equal(t, ConsensusClique, detected)
+	parsed, err := ParseHeaderByConsensus(checkpoint.Base, detected)
+	require.NoError(t, err)
+	require.Equal(t, initial, parsed.Validators)
+	signer, err := parsed.CliqueSigner()
+	require.NoError(t, err)
+	require.Equal(t, initial[0], signer)
+	sealingHeader, err := parsed.GetSealingHeaderBytes()
+	require.NoError(t, err)
+	require.Equal(t, clique.SealHash(checkpoint.Base), crypto.Keccak256Hash(sealingHeader))
+	hash, err := parsed.BlockHash()
+	require.NoError(t, err)
+	require.Equal(t, checkpoint.Base.Hash(), hash)
+	_, err = parsed.ValidateAndGetCommitSeals()
+	require.Error(t, err)
+
+	tracker, err := NewCliqueTracker(epoch, parsed)
+	require.NoError(t, err)
+	require.Equal(t, initial, tracker.Signers())
+
+	// next returns the next header signed by the in-turn signer
+	next := func(vote *Vote) *ParsedHeader {
+		height := tracker.Height() + 1
+		current := tracker.Signers()
+		signer := current[height%uint64(len(current))]
+		var validators []common.Address
+		if height%epoch == 0 {
+			validators = current
+		}
+		return buildCliqueHeader(t, height, validators, vote, cliqueDiffInTurn, keys[signer])
+	}
+
+	// 2. The candidate is added by the majority of the signers
+	add := &Vote{Recipient: candidate, Type: VoteAdd}
+	for i := 0; i < 2; i++ {
+		_, err := tracker.Apply(next(add))
+		require.NoError(t, err)
+	}
+	require.Equal(t, signers, tracker.Signers())
+	require.Equal(t, []uint64{2}, tracker.Transitions())
+
+	// 3. The checkpoint must list the current signers
+	for tracker.Height() < epoch-1 {
+		_, err := tracker.Apply(next(nil))
+		require.NoError(t, err)
+	}
+	invalid := buildCliqueHeader(t, epoch, initial, nil, cliqueDiffInTurn, keys[signers[epoch%4]])
+	_, err = tracker.Apply(invalid)
+	require.Error(t, err)
+	_, err = tracker.Apply(next(nil))
+	require.NoError(t, err)
+
+	// 4. Invalid headers are rejected
+	outsider, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	for name, header := range map[string]*ParsedHeader{
+		"unauthorized":   buildCliqueHeader(t, epoch+1, nil, nil, cliqueDiffNoTurn, outsider),
+		"recently":       buildCliqueHeader(t, epoch+1, nil, nil, cliqueDiffNoTurn, keys[signers[epoch%4]]),
+		"difficulty":     buildCliqueHeader(t, epoch+1, nil, nil, cliqueDiffNoTurn, keys[signers[(epoch+1)%4]]),
+		"non-contiguous": buildCliqueHeader(t, epoch+2, nil, nil, cliqueDiffInTurn, keys[signers[(epoch+2)%4]]),
+	} {
+		_, err := tracker.Apply(header)
+		require.Error(t, err, name)
+	}
+}
+
+func buildCliqueHeader(t *testing.T, height uint64, signers []common.Address, vote *Vote, difficulty int64, key *ecdsa.PrivateKey) *ParsedHeader {
+	header := &gethtypes.Header{
+		Number:     new(big.Int).SetUint64(height),
+		Difficulty: big.NewInt(difficulty),
+		GasLimit:   1000000,
+		Time:       100 + height,
+		Extra:      make([]byte, cliqueVanityLength, cliqueVanityLength+len(signers)*common.AddressLength+cliqueSealLength),
+	}
+	for _, signer := range signers {
+		header.Extra = append(header.Extra, signer.Bytes()...)
+	}
+	if vote != nil {
+		header.Coinbase = vote.Recipient
+		if vote.Type == VoteAdd {
+			header.Nonce = cliqueNonceAuthVote
+		}
+	}
+	header.Extra = append(header.Extra, make([]byte, cliqueSealLength)...)
+	sig, err := crypto.Sign(clique.SealHash(header).Bytes(), key)
+	require.NoError(t, err)
+	copy(header.Extra[len(header.Extra)-cliqueSealLength:], sig)
+	parsed, err := ParseHeaderByConsensus(header, ConsensusClique)
+	require.NoError(t, err)
+	return parsed
+}
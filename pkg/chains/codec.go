@@ -0,0 +1,107 @@
+package chains
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// HeaderFork is the shape of a block header determined by the hard forks activated on the chain
+type HeaderFork string
+
+const (
+	// HeaderForkPreLondon is the header with the 15 fields of the original Ethereum header
+	HeaderForkPreLondon HeaderFork = "pre-london"
+	// HeaderForkLondon appends baseFee(EIP-1559)
+	HeaderForkLondon HeaderFork = "london"
+	// HeaderForkShanghai appends withdrawalsRoot(EIP-4895) after baseFee
+	HeaderForkShanghai HeaderFork = "shanghai"
+)
+
+// DetectHeaderFork returns the shape of the header from the presence of the fields added by the hard forks.
+// It returns an error if the header has a field that Besu doesn't hash or the fields are inconsistent.
+func DetectHeaderFork(header *gethtypes.Header) (HeaderFork, error) {
+	switch {
+	case header.ExcessDataGas != nil:
+		return "", fmt.Errorf("unsupported header field: excessDataGas")
+	case header.BaseFee == nil && header.WithdrawalsHash != nil:
+		return "", fmt.Errorf("withdrawalsRoot requires baseFee")
+	case header.WithdrawalsHash != nil:
+		return HeaderForkShanghai, nil
+	case header.BaseFee != nil:
+		return HeaderForkLondon, nil
+	default:
+		return HeaderForkPreLondon, nil
+	}
+}
+
+// EncodeBesuHeader encodes the header with the extra data in the field order of Besu's BlockHeader.
+// Unlike the RLP encoding of gethtypes.Header, it only encodes the fields of the detected fork.
+func EncodeBesuHeader(header *gethtypes.Header, extra []byte) ([]byte, error) {
+	fork, err := DetectHeaderFork(header)
+	if err != nil {
+		return nil, err
+	}
+	items := []interface{}{
+		header.ParentHash,
+		header.UncleHash,
+		header.Coinbase,
+		header.Root,
+		header.TxHash,
+		header.ReceiptHash,
+		header.Bloom,
+		header.Difficulty,
+		header.Number,
+		header.GasLimit,
+		header.GasUsed,
+		header.Time,
+		extra,
+		header.MixDigest,
+		header.Nonce,
+	}
+	switch fork {
+	case HeaderForkLondon:
+		items = append(items, header.BaseFee)
+	case HeaderForkShanghai:
+		items = append(items, header.BaseFee, *header.WithdrawalsHash)
+	}
+	return rlp.EncodeToBytes(items)
+}
+
+// HeaderHashMismatchError is returned when the block hash recomputed from the header doesn't match the hash returned by the node
+type HeaderHashMismatchError struct {
+	Height   uint64
+	Fork     HeaderFork
+	Computed common.Hash
+	Expected common.Hash
+}
+
+func (e *HeaderHashMismatchError) Error() string {
+	return fmt.Sprintf("besu header hash mismatch: height=%v fork=%v computed=%v expected=%v", e.Height, e.Fork, e.Computed, e.Expected)
+}
+
+// BlockHash returns the block hash computed in the same way as Besu
+func (h ParsedHeader) BlockHash() (common.Hash, error) {
+	bz, err := h.GetChainHeaderBytes()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(bz), nil
+}
+
+// VerifyBlockHash checks that the block hash computed from the header matches the hash returned by the node.
+// A mismatch means the header re-encoded for the seal verification is also different from what Besu signed.
+func (h ParsedHeader) VerifyBlockHash(expected common.Hash) error {
+	computed, err := h.BlockHash()
+	if err != nil {
+		return err
+	}
+	if computed != expected {
+		fork, _ := DetectHeaderFork(h.Base)
+		return &HeaderHashMismatchError{Height: h.Base.Number.Uint64(), Fork: fork, Computed: computed, Expected: expected}
+	}
+	return nil
+}
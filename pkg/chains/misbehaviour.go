@@ -0,0 +1,217 @@
+package chains
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Evidence is a proof of misbehaviour: two validly sealed headers at the same height with different hashes
+type Evidence struct {
+	Height  uint64
+	Header1 *ParsedHeader
+	Header2 *ParsedHeader
+	// Seals1 and Seals2 are the commit seals of the headers ordered by their validators. A missing seal is nil.
+	Seals1 [][]byte
+	Seals2 [][]byte
+	// DoubleSigners are the validators that signed both headers
+	DoubleSigners []common.Address
+}
+
+func (e Evidence) String() string {
+	return fmt.Sprintf("conflicting headers: height=%v hash1=%v hash2=%v double-signers=%v", e.Height, e.Header1.Base.Hash(), e.Header2.Base.Hash(), e.DoubleSigners)
+}
+
+// RootConflict is a mismatch between the storage root of IBCStore proven by a validly sealed header and the root stored in the counterparty's consensus state
+type RootConflict struct {
+	Height      uint64
+	Header      *ParsedHeader
+	StorageRoot common.Hash
+	StoredRoot  []byte
+}
+
+func (c RootConflict) String() string {
+	return fmt.Sprintf("storage root mismatch: height=%v hash=%v expected=%v stored=0x%x", c.Height, c.Header.Base.Hash(), c.StorageRoot, c.StoredRoot)
+}
+
+// MisbehaviourDetector keeps validly sealed headers by height and detects conflicting headers.
+type MisbehaviourDetector struct {
+	consensus ConsensusType
+
+	mu sync.Mutex
+	// headers are the headers seen at each height keyed by the chain hash
+	headers map[uint64]map[common.Hash]*sealedHeader
+}
+
+type sealedHeader struct {
+	header *ParsedHeader
+	seals  [][]byte
+}
+
+// NewMisbehaviourDetector creates a detector. If the consensus type is empty, it is detected from each header by DetectConsensusType.
+func NewMisbehaviourDetector(consensus ConsensusType) *MisbehaviourDetector {
+	return &MisbehaviourDetector{
+		consensus: consensus,
+		headers:   make(map[uint64]map[common.Hash]*sealedHeader),
+	}
+}
+
+// AddHeader validates the seals of the header and compares it with the headers seen at the same height.
+// It returns evidences if the header conflicts with them. It returns an error if the header is not validly sealed.
+func (d *MisbehaviourDetector) AddHeader(header *gethtypes.Header) ([]*Evidence, error) {
+	evidences, _, err := d.addHeader(header)
+	return evidences, err
+}
+
+// addHeader adds the header and returns the evidences and the chain hash of the header
+func (d *MisbehaviourDetector) addHeader(header *gethtypes.Header) ([]*Evidence, common.Hash, error) {
+	consensus := d.consensus
+	if consensus == "" {
+		var err error
+		if consensus, err = DetectConsensusType(header); err != nil {
+			return nil, common.Hash{}, err
+		}
+	}
+	parsed, err := ParseHeaderByConsensus(header, consensus)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	seals, err := parsed.ValidateAndGetCommitSeals()
+	if err != nil {
+		return nil, common.Hash{}, fmt.Errorf("invalid seals: height=%v err=%v", header.Number, err)
+	}
+	chainHeader, err := parsed.GetChainHeaderBytes()
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	hash := crypto.Keccak256Hash(chainHeader)
+	height := header.Number.Uint64()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	seen, ok := d.headers[height]
+	if !ok {
+		seen = make(map[common.Hash]*sealedHeader)
+		d.headers[height] = seen
+	}
+	if _, ok := seen[hash]; ok {
+		return nil, hash, nil
+	}
+	var evidences []*Evidence
+	for _, other := range sortedHeaders(seen) {
+		evidences = append(evidences, &Evidence{
+			Height:        height,
+			Header1:       other.header,
+			Header2:       parsed,
+			Seals1:        other.seals,
+			Seals2:        seals,
+			DoubleSigners: doubleSigners(other.header, other.seals, parsed, seals),
+		})
+	}
+	seen[hash] = &sealedHeader{header: parsed, seals: seals}
+	return evidences, hash, nil
+}
+
+// FetchAndCheck fetches the headers at the height from the endpoints and adds them to the detector.
+// The headers that are not validly sealed are ignored, but an error is returned if no endpoint returns a valid header.
+func (d *MisbehaviourDetector) FetchAndCheck(ctx context.Context, fetchers []HeaderFetcher, height uint64) ([]*Evidence, error) {
+	var (
+		evidences []*Evidence
+		errs      []error
+		valid     int
+	)
+	for i, fetcher := range fetchers {
+		header, err := fetcher.HeaderByNumber(ctx, new(big.Int).SetUint64(height))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("endpoint %v: %v", i, err))
+			continue
+		}
+		evs, err := d.AddHeader(header)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("endpoint %v: %v", i, err))
+			continue
+		}
+		valid++
+		evidences = append(evidences, evs...)
+	}
+	if valid == 0 {
+		return nil, fmt.Errorf("no valid header at height %v: %v", height, errs)
+	}
+	return evidences, nil
+}
+
+// CheckStoredRoot compares the storage root of IBCStore proven by the validly sealed header seen at the height with the root stored in the counterparty's consensus state.
+// `storageRoot` must be verified against the state root of the header by the caller.
+// The header is also added to the detector, so the evidences of conflicts with the headers seen before are returned as well.
+func (d *MisbehaviourDetector) CheckStoredRoot(header *gethtypes.Header, storageRoot common.Hash, storedRoot []byte) (*RootConflict, []*Evidence, error) {
+	evidences, hash, err := d.addHeader(header)
+	if err != nil {
+		return nil, nil, err
+	}
+	if bytes.Equal(storageRoot.Bytes(), storedRoot) {
+		return nil, evidences, nil
+	}
+	height := header.Number.Uint64()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// the block may have been seen first with another round or seal set, so it's looked up by the chain hash
+	return &RootConflict{
+		Height:      height,
+		Header:      d.headers[height][hash].header,
+		StorageRoot: storageRoot,
+		StoredRoot:  storedRoot,
+	}, evidences, nil
+}
+
+// Prune discards the headers below the height
+func (d *MisbehaviourDetector) Prune(below uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for height := range d.headers {
+		if height < below {
+			delete(d.headers, height)
+		}
+	}
+}
+
+func sortedHeaders(headers map[common.Hash]*sealedHeader) []*sealedHeader {
+	var hashes []common.Hash
+	for hash := range headers {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+	var sorted []*sealedHeader
+	for _, hash := range hashes {
+		sorted = append(sorted, headers[hash])
+	}
+	return sorted
+}
+
+// doubleSigners returns the validators of both headers that signed both
+func doubleSigners(h1 *ParsedHeader, seals1 [][]byte, h2 *ParsedHeader, seals2 [][]byte) []common.Address {
+	signed1 := make(map[common.Address]struct{})
+	for i, seal := range seals1 {
+		if seal != nil {
+			signed1[h1.Validators[i]] = struct{}{}
+		}
+	}
+	var signers []common.Address
+	for i, seal := range seals2 {
+		if seal == nil {
+			continue
+		}
+		if _, ok := signed1[h2.Validators[i]]; ok {
+			signers = append(signers, h2.Validators[i])
+		}
+	}
+	return signers
+}
@@ -0,0 +1,84 @@
+package chains
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMisbehaviourDetector(t *testing.T) {
+	var keys []*ecdsa.PrivateKey
+	for i := 0; i < 4; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	vals := SignerAddresses(keys)
+	build := func(time uint64, round uint32, opts ...HeaderOption) *gethtypes.Header {
+		h, err := BuildHeader(&gethtypes.Header{Number: big.NewInt(10), Difficulty: big.NewInt(1), GasLimit: 1000000, Time: time}, keys, round, nil, opts...)
+		require.NoError(t, err)
+		return h.Base
+	}
+	detector := NewMisbehaviourDetector("")
+
+	// 1. No evidence for the same block even if it is sealed in another round
+	header := build(100, 0, WithMissingSeals(0))
+	evidences, err := detector.AddHeader(header)
+	require.NoError(t, err)
+	require.Empty(t, evidences)
+	evidences, err = detector.AddHeader(build(100, 1))
+	require.NoError(t, err)
+	require.Empty(t, evidences)
+
+	// 2. A header that is not validly sealed is rejected
+	_, err = detector.AddHeader(build(200, 0, WithMissingSeals(0, 1)))
+	require.Error(t, err)
+
+	// 3. A conflicting header is detected from another endpoint
+	conflicting := build(200, 0, WithMissingSeals(3))
+	evidences, err = detector.FetchAndCheck(context.Background(), []HeaderFetcher{
+		testHeaderFetcher{10: header},
+		testHeaderFetcher{10: conflicting},
+	}, 10)
+	require.NoError(t, err)
+	require.Len(t, evidences, 1)
+	require.Equal(t, uint64(10), evidences[0].Height)
+	require.Equal(t, conflicting.Hash(), evidences[0].Header2.Base.Hash())
+	require.Nil(t, evidences[0].Seals2[3])
+	require.Equal(t, []common.Address{vals[1], vals[2]}, evidences[0].DoubleSigners)
+
+	// 4. The stored root is compared with the proven storage root
+	conflict, evidences, err := detector.CheckStoredRoot(header, common.Hash{1}, common.Hash{1}.Bytes())
+	require.NoError(t, err)
+	require.Nil(t, conflict)
+	require.Empty(t, evidences)
+	conflict, _, err = detector.CheckStoredRoot(header, common.Hash{1}, common.Hash{2}.Bytes())
+	require.NoError(t, err)
+	require.NotNil(t, conflict)
+	require.Equal(t, header.Hash(), conflict.Header.Base.Hash())
+
+	// 5. The same block with another seal set refers to the header seen first
+	conflict, evidences, err = detector.CheckStoredRoot(build(100, 0, WithMissingSeals(1)), common.Hash{1}, common.Hash{2}.Bytes())
+	require.NoError(t, err)
+	require.Empty(t, evidences)
+	require.NotNil(t, conflict)
+	require.Equal(t, header.Hash(), conflict.Header.Base.Hash())
+	require.NotEmpty(t, conflict.String())
+
+	// 6. The evidences of a conflicting header are returned with the root check
+	_, evidences, err = detector.CheckStoredRoot(build(400, 0), common.Hash{1}, common.Hash{1}.Bytes())
+	require.NoError(t, err)
+	require.Len(t, evidences, 2)
+
+	// 7. Pruned headers are not compared
+	detector.Prune(11)
+	evidences, err = detector.AddHeader(build(300, 0))
+	require.NoError(t, err)
+	require.Empty(t, evidences)
+}
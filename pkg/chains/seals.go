@@ -0,0 +1,123 @@
+package chains
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+)
+
+const defaultSealCacheSize = 1024
+
+// DefaultSealVerifier is the SealVerifier used by ParsedHeader.ValidateAndGetCommitSeals
+var DefaultSealVerifier = NewSealVerifier(runtime.GOMAXPROCS(0), defaultSealCacheSize)
+
+// SealVerifier recovers the committers of commit seals with bounded parallelism.
+// The results are cached in an LRU keyed by the header hash, so parsing the same header again does not repeat ecrecover.
+type SealVerifier struct {
+	parallelism int
+	cache       *lru.Cache[common.Hash, recoveredSeals]
+}
+
+type recoveredSeals struct {
+	seals [][]byte
+	vals  map[common.Address][]byte
+}
+
+// NewSealVerifier returns a SealVerifier that runs at most `parallelism` ecrecovers at once and caches the results of `cacheSize` headers.
+func NewSealVerifier(parallelism int, cacheSize int) *SealVerifier {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	if cacheSize < 1 {
+		cacheSize = 1
+	}
+	return &SealVerifier{
+		parallelism: parallelism,
+		cache:       lru.NewCache[common.Hash, recoveredSeals](cacheSize),
+	}
+}
+
+// RecoverCommitterAddressesVals returns the same result as RecoverCommitterAddressesVals.
+// The cached result is used only if the seals are identical to the cached ones.
+func (v *SealVerifier) RecoverCommitterAddressesVals(headerHash []byte, seals [][]byte) (map[common.Address][]byte, error) {
+	key := common.BytesToHash(headerHash)
+	if cached, ok := v.cache.Get(key); ok && equalSeals(cached.seals, seals) {
+		return copyVals(cached.vals), nil
+	}
+	vals, err := recoverCommitterAddressesValsParallel(headerHash, seals, v.parallelism)
+	if err != nil {
+		return nil, err
+	}
+	v.cache.Add(key, recoveredSeals{seals: copySeals(seals), vals: copyVals(vals)})
+	return vals, nil
+}
+
+// Purge removes all cached results
+func (v *SealVerifier) Purge() {
+	v.cache.Purge()
+}
+
+func recoverCommitterAddressesValsParallel(headerHash []byte, seals [][]byte, parallelism int) (map[common.Address][]byte, error) {
+	if parallelism <= 1 || len(seals) <= 1 {
+		return RecoverCommitterAddressesVals(headerHash, seals)
+	}
+	addrs := make([]common.Address, len(seals))
+	errs := make([]error, len(seals))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism && i < len(seals); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				addrs[idx], errs[idx] = ECRecoverAddress(headerHash, seals[idx])
+			}
+		}()
+	}
+	for i := range seals {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	// keep the semantics of the sequential version: the first error is returned and a later seal of the same signer wins
+	vals := make(map[common.Address][]byte)
+	for i, seal := range seals {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		vals[addrs[i]] = seal
+	}
+	return vals, nil
+}
+
+func equalSeals(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func copySeals(seals [][]byte) [][]byte {
+	res := make([][]byte, len(seals))
+	for i, seal := range seals {
+		res[i] = common.CopyBytes(seal)
+	}
+	return res
+}
+
+func copyVals(vals map[common.Address][]byte) map[common.Address][]byte {
+	res := make(map[common.Address][]byte, len(vals))
+	for addr, seal := range vals {
+		res[addr] = common.CopyBytes(seal)
+	}
+	return res
+}
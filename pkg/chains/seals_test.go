@@ -0,0 +1,89 @@
+package chains
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"testing"
+
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealVerifier(t *testing.T) {
+	hash, seals := buildSeals(t, 10)
+	expected, err := RecoverCommitterAddressesVals(hash, seals)
+	require.NoError(t, err)
+
+	// 1. The parallel recovery returns the same result as the sequential one
+	verifier := NewSealVerifier(4, 2)
+	vals, err := verifier.RecoverCommitterAddressesVals(hash, seals)
+	require.NoError(t, err)
+	require.Equal(t, expected, vals)
+	require.Equal(t, 1, verifier.cache.Len())
+
+	// 2. The cached result is returned and modifying it does not affect the cache
+	for addr := range vals {
+		delete(vals, addr)
+	}
+	vals, err = verifier.RecoverCommitterAddressesVals(hash, seals)
+	require.NoError(t, err)
+	require.Equal(t, expected, vals)
+
+	// 3. Different seals for the same header hash are not served from the cache
+	vals, err = verifier.RecoverCommitterAddressesVals(hash, seals[:5])
+	require.NoError(t, err)
+	require.Len(t, vals, 5)
+
+	// 4. An invalid seal fails regardless of the parallelism
+	invalid := append(append([][]byte{}, seals...), make([]byte, 65))
+	_, err = RecoverCommitterAddressesVals(hash, invalid)
+	require.Error(t, err)
+	_, err = verifier.RecoverCommitterAddressesVals(hash, invalid)
+	require.Error(t, err)
+}
+
+func BenchmarkRecoverCommitterAddressesVals(b *testing.B) {
+	for _, size := range []int{4, 10, 25, 50, 100} {
+		hash, seals := buildSeals(b, size)
+		b.Run(fmt.Sprintf("sequential/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := RecoverCommitterAddressesVals(hash, seals); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("parallel/%d", size), func(b *testing.B) {
+			verifier := NewSealVerifier(DefaultSealVerifier.parallelism, 1)
+			for i := 0; i < b.N; i++ {
+				verifier.Purge()
+				if _, err := verifier.RecoverCommitterAddressesVals(hash, seals); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("cached/%d", size), func(b *testing.B) {
+			verifier := NewSealVerifier(DefaultSealVerifier.parallelism, 1)
+			for i := 0; i < b.N; i++ {
+				if _, err := verifier.RecoverCommitterAddressesVals(hash, seals); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func buildSeals(t testing.TB, size int) ([]byte, [][]byte) {
+	var keys []*ecdsa.PrivateKey
+	for i := 0; i < size; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	header, err := BuildHeader(&gethtypes.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), GasLimit: 1000000, Time: 100}, keys, 0, nil)
+	require.NoError(t, err)
+	bz, err := header.GetSealingHeaderBytes()
+	require.NoError(t, err)
+	return crypto.Keccak256(bz), header.Seals
+}
@@ -0,0 +1,172 @@
+package chains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// VoteType is a type of a validator vote. The values are determined by Besu.
+type VoteType byte
+
+const (
+	VoteDrop VoteType = 0x00
+	VoteAdd  VoteType = 0xFF
+)
+
+func (t VoteType) String() string {
+	switch t {
+	case VoteAdd:
+		return "add"
+	case VoteDrop:
+		return "drop"
+	default:
+		return fmt.Sprintf("unknown(0x%x)", byte(t))
+	}
+}
+
+// Vote is a vote of the proposer to add or drop the recipient to/from the validator set.
+type Vote struct {
+	Recipient common.Address
+	Type      VoteType
+}
+
+func (v Vote) String() string {
+	return fmt.Sprintf("%v %v", v.Type, v.Recipient)
+}
+
+// decodeVote decodes an encoded vote. It returns nil if the vote is empty:
+// IBFT2 encodes no vote as an empty string, but QBFT encodes it as an empty list.
+func decodeVote(raw rlp.RawValue) (*Vote, error) {
+	kind, content, _, err := rlp.Split(raw)
+	if err != nil {
+		return nil, err
+	} else if len(content) == 0 {
+		return nil, nil
+	} else if kind != rlp.List {
+		return nil, fmt.Errorf("vote must be a list: %x", []byte(raw))
+	}
+	// NOTE: the type is encoded as a single byte string instead of an integer, so VoteDrop is encoded as 0x00
+	var vote struct {
+		Recipient common.Address
+		Type      []byte
+	}
+	if err := rlp.DecodeBytes(raw, &vote); err != nil {
+		return nil, err
+	} else if len(vote.Type) != 1 {
+		return nil, fmt.Errorf("invalid length of vote type: %v", len(vote.Type))
+	}
+	if t := VoteType(vote.Type[0]); t != VoteAdd && t != VoteDrop {
+		return nil, fmt.Errorf("invalid vote type: %v", t)
+	}
+	return &Vote{Recipient: vote.Recipient, Type: VoteType(vote.Type[0])}, nil
+}
+
+// encodeVote encodes the vote with the encoding of the consensus type
+func encodeVote(vote *Vote, consensus ConsensusType) (rlp.RawValue, error) {
+	if vote == nil {
+		if consensus == ConsensusQBFT {
+			return rlp.EncodeToBytes([]interface{}{})
+		}
+		return rlp.EncodeToBytes([]byte{})
+	}
+	return rlp.EncodeToBytes([]interface{}{vote.Recipient, []byte{byte(vote.Type)}})
+}
+
+// HeaderFetcher fetches a header of the number. ethclient.Client satisfies it.
+type HeaderFetcher interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*gethtypes.Header, error)
+}
+
+// HeaderVote is a vote included in the header
+type HeaderVote struct {
+	Height   uint64
+	Proposer common.Address
+	Vote     Vote
+}
+
+// ValidatorTransition is a change of the validator set
+type ValidatorTransition struct {
+	// Height is the number of the first block signed by the new validator set
+	Height     uint64
+	Previous   []common.Address
+	Validators []common.Address
+	Added      []common.Address
+	Removed    []common.Address
+	// Votes are the votes since the previous transition or the beginning of the range
+	Votes []HeaderVote
+}
+
+// ScanValidatorTransitions scans the headers in [from, to] and returns the changes of the validator set.
+// A transition at the height `from` is never reported because the previous validator set is unknown.
+// If the consensus type is empty, it is detected from each header.
+func ScanValidatorTransitions(ctx context.Context, fetcher HeaderFetcher, consensus ConsensusType, from, to uint64) ([]ValidatorTransition, error) {
+	if from > to {
+		return nil, errors.New("`from` must be less than or equal to `to`")
+	}
+	var (
+		transitions []ValidatorTransition
+		votes       []HeaderVote
+		previous    []common.Address
+	)
+	for height := from; height <= to; height++ {
+		header, err := fetcher.HeaderByNumber(ctx, new(big.Int).SetUint64(height))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the header: height=%v err=%v", height, err)
+		}
+		ct := consensus
+		if ct == "" {
+			if ct, err = DetectConsensusType(header); err != nil {
+				return nil, err
+			}
+		}
+		parsed, err := ParseHeaderByConsensus(header, ct)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the header: height=%v err=%v", height, err)
+		}
+		if height > from {
+			if added, removed := diffValidators(previous, parsed.Validators); len(added) > 0 || len(removed) > 0 {
+				transitions = append(transitions, ValidatorTransition{
+					Height:     height,
+					Previous:   previous,
+					Validators: parsed.Validators,
+					Added:      added,
+					Removed:    removed,
+					Votes:      votes,
+				})
+				votes = nil
+			}
+		}
+		if parsed.Vote != nil {
+			votes = append(votes, HeaderVote{Height: height, Proposer: header.Coinbase, Vote: *parsed.Vote})
+		}
+		previous = parsed.Validators
+	}
+	return transitions, nil
+}
+
+// diffValidators returns the validators added to and removed from `prev` in `next`
+func diffValidators(prev, next []common.Address) (added, removed []common.Address) {
+	prevSet := make(map[common.Address]struct{}, len(prev))
+	for _, v := range prev {
+		prevSet[v] = struct{}{}
+	}
+	nextSet := make(map[common.Address]struct{}, len(next))
+	for _, v := range next {
+		nextSet[v] = struct{}{}
+		if _, ok := prevSet[v]; !ok {
+			added = append(added, v)
+		}
+	}
+	for _, v := range prev {
+		if _, ok := nextSet[v]; !ok {
+			removed = append(removed, v)
+		}
+	}
+	return added, removed
+}
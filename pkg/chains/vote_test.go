@@ -0,0 +1,78 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoteEncoding(t *testing.T) {
+	recipient := common.HexToAddress("0x01")
+	for _, consensus := range []ConsensusType{ConsensusIBFT2, ConsensusQBFT} {
+		for _, vote := range []*Vote{nil, {Recipient: recipient, Type: VoteAdd}, {Recipient: recipient, Type: VoteDrop}} {
+			raw, err := encodeVote(vote, consensus)
+			require.NoError(t, err)
+			decoded, err := decodeVote(raw)
+			require.NoError(t, err)
+			require.Equal(t, vote, decoded)
+		}
+	}
+	// the vote type is encoded as a single byte like Besu
+	raw, err := encodeVote(&Vote{Recipient: recipient, Type: VoteDrop}, ConsensusIBFT2)
+	require.NoError(t, err)
+	require.Equal(t, byte(0x00), raw[len(raw)-1])
+
+	invalid, err := rlp.EncodeToBytes([]interface{}{recipient, []byte{0x01}})
+	require.NoError(t, err)
+	_, err = decodeVote(invalid)
+	require.Error(t, err)
+}
+
+func TestScanValidatorTransitions(t *testing.T) {
+	a, b, c := common.HexToAddress("0x0a"), common.HexToAddress("0x0b"), common.HexToAddress("0x0c")
+	fetcher := testHeaderFetcher{}
+	addHeader := func(number uint64, validators []common.Address, vote *Vote) {
+		raw, err := encodeVote(vote, ConsensusIBFT2)
+		require.NoError(t, err)
+		extra, err := rlp.EncodeToBytes([]interface{}{[32]byte{}, validators, raw, [4]byte{}, [][]byte{}})
+		require.NoError(t, err)
+		fetcher[number] = &gethtypes.Header{Number: new(big.Int).SetUint64(number), Coinbase: a, Extra: extra}
+	}
+	addHeader(1, []common.Address{a, b}, nil)
+	addHeader(2, []common.Address{a, b}, &Vote{Recipient: c, Type: VoteAdd})
+	addHeader(3, []common.Address{a, b, c}, &Vote{Recipient: b, Type: VoteDrop})
+	addHeader(4, []common.Address{a, c}, nil)
+	addHeader(5, []common.Address{a, c}, nil)
+
+	transitions, err := ScanValidatorTransitions(context.Background(), fetcher, "", 1, 5)
+	require.NoError(t, err)
+	require.Len(t, transitions, 2)
+	require.Equal(t, uint64(3), transitions[0].Height)
+	require.Equal(t, []common.Address{c}, transitions[0].Added)
+	require.Empty(t, transitions[0].Removed)
+	require.Equal(t, []HeaderVote{{Height: 2, Proposer: a, Vote: Vote{Recipient: c, Type: VoteAdd}}}, transitions[0].Votes)
+	require.Equal(t, uint64(4), transitions[1].Height)
+	require.Equal(t, []common.Address{b}, transitions[1].Removed)
+	require.Equal(t, []common.Address{a, c}, transitions[1].Validators)
+
+	// no transition is reported at `from`
+	transitions, err = ScanValidatorTransitions(context.Background(), fetcher, ConsensusIBFT2, 4, 5)
+	require.NoError(t, err)
+	require.Empty(t, transitions)
+}
+
+type testHeaderFetcher map[uint64]*gethtypes.Header
+
+func (f testHeaderFetcher) HeaderByNumber(ctx context.Context, number *big.Int) (*gethtypes.Header, error) {
+	h, ok := f[number.Uint64()]
+	if !ok {
+		return nil, fmt.Errorf("header not found: %v", number)
+	}
+	return h, nil
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/chains"
+)
+
+// BesuClient extends ETHClient with the consensus APIs of Besu: `ibft_*` for IBFT2 and `qbft_*` for QBFT
+type BesuClient struct {
+	*ETHClient
+	consensus chains.ConsensusType
+}
+
+// SignerMetric is the block production of a validator returned by `ibft_getSignerMetrics`
+type SignerMetric struct {
+	Address                 common.Address `json:"address"`
+	ProposedBlockCount      hexutil.Uint64 `json:"proposedBlockCount"`
+	LastProposedBlockNumber hexutil.Uint64 `json:"lastProposedBlockNumber"`
+}
+
+// ValidatorSetMismatchError is returned when the validators returned by the node differ from the validators in the extra data of the header
+type ValidatorSetMismatchError struct {
+	Height uint64
+	// Header is the validators in the extra data
+	Header []common.Address
+	// Node is the validators returned by the consensus API
+	Node []common.Address
+}
+
+func (e *ValidatorSetMismatchError) Error() string {
+	return fmt.Sprintf("validator set mismatch: height=%v header=%v node=%v", e.Height, e.Header, e.Node)
+}
+
+// NewBesuClient returns a BesuClient that calls the APIs of the consensus type
+func NewBesuClient(cl *ETHClient, consensus chains.ConsensusType) (*BesuClient, error) {
+	switch consensus {
+	case chains.ConsensusIBFT2, chains.ConsensusQBFT:
+	default:
+		return nil, fmt.Errorf("unknown consensus type: %v", consensus)
+	}
+	return &BesuClient{ETHClient: cl, consensus: consensus}, nil
+}
+
+// Consensus returns the consensus type of the client
+func (cl *BesuClient) Consensus() chains.ConsensusType {
+	return cl.consensus
+}
+
+// GetValidatorsByBlockNumber returns the validators of the block. If the number is nil, it returns the validators of the latest block.
+func (cl *BesuClient) GetValidatorsByBlockNumber(ctx context.Context, number *big.Int) ([]common.Address, error) {
+	var validators []common.Address
+	if err := cl.rpcClient.CallContext(ctx, &validators, cl.method("getValidatorsByBlockNumber"), toBlockNumber(number)); err != nil {
+		return nil, err
+	}
+	return validators, nil
+}
+
+// GetPendingVotes returns the votes that the node will propose in its next blocks
+func (cl *BesuClient) GetPendingVotes(ctx context.Context) (map[common.Address]chains.VoteType, error) {
+	var res map[common.Address]bool
+	if err := cl.rpcClient.CallContext(ctx, &res, cl.method("getPendingVotes")); err != nil {
+		return nil, err
+	}
+	votes := make(map[common.Address]chains.VoteType, len(res))
+	for addr, add := range res {
+		if add {
+			votes[addr] = chains.VoteAdd
+		} else {
+			votes[addr] = chains.VoteDrop
+		}
+	}
+	return votes, nil
+}
+
+// GetSignerMetrics returns the block production of the validators in the range. A nil bound means the node's default.
+// The bounds are positional parameters, so `to` can't be given without `from`.
+func (cl *BesuClient) GetSignerMetrics(ctx context.Context, from, to *big.Int) ([]SignerMetric, error) {
+	var metrics []SignerMetric
+	var args []interface{}
+	if from == nil && to != nil {
+		return nil, errors.New("`from` must be given with `to`")
+	} else if from != nil {
+		args = append(args, toBlockNumber(from))
+		if to != nil {
+			args = append(args, toBlockNumber(to))
+		}
+	}
+	if err := cl.rpcClient.CallContext(ctx, &metrics, cl.method("getSignerMetrics"), args...); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// CheckValidators compares the validators returned by the node with the validators in the extra data of the header at the same height.
+// If the number is nil, the latest header is used. It returns ValidatorSetMismatchError if they differ.
+// The latest block is resolved to a height first so that the header and the validators are queried at the same block.
+func (cl *BesuClient) CheckValidators(ctx context.Context, number *big.Int) error {
+	if number == nil {
+		latest, err := cl.BlockNumber(ctx)
+		if err != nil {
+			return err
+		}
+		number = new(big.Int).SetUint64(latest)
+	}
+	ctx = WithPinnedHeight(ctx, number)
+	header, err := cl.HeaderByRef(ctx, BlockRef(number))
+	if err != nil {
+		return err
+	}
+	parsed, err := chains.ParseHeaderByConsensus(header, cl.consensus)
+	if err != nil {
+		return err
+	}
+	validators, err := cl.GetValidatorsByBlockNumber(ctx, number)
+	if err != nil {
+		return err
+	}
+	if !sameAddresses(parsed.Validators, validators) {
+		return &ValidatorSetMismatchError{Height: header.Number.Uint64(), Header: parsed.Validators, Node: validators}
+	}
+	return nil
+}
+
+func (cl *BesuClient) method(name string) string {
+	return fmt.Sprintf("%s_%s", cl.consensus, name)
+}
+
+func toBlockNumber(number *big.Int) rpc.BlockNumber {
+	if number == nil {
+		return rpc.LatestBlockNumber
+	}
+	return rpc.BlockNumber(number.Int64())
+}
+
+// sameAddresses returns true if the lists have the same addresses regardless of the order
+func sameAddresses(a, b []common.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sorted := func(addrs []common.Address) []common.Address {
+		res := append([]common.Address{}, addrs...)
+		sort.Slice(res, func(i, j int) bool { return res[i].Hex() < res[j].Hex() })
+		return res
+	}
+	x, y := sorted(a), sorted(b)
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
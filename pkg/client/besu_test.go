@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/chains"
+)
+
+func TestBesuClient(t *testing.T) {
+	var keys []*ecdsa.PrivateKey
+	for i := 0; i < 4; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	validators := chains.SignerAddresses(keys)
+	candidate := common.HexToAddress("0x01")
+
+	for _, consensus := range []chains.ConsensusType{chains.ConsensusIBFT2, chains.ConsensusQBFT} {
+		t.Run(string(consensus), func(t *testing.T) {
+			header, err := chains.BuildHeader(
+				&gethtypes.Header{Number: big.NewInt(10), Difficulty: big.NewInt(1), GasLimit: 1000000, Time: 100},
+				keys, 0, nil, chains.WithConsensusType(consensus),
+			)
+			require.NoError(t, err)
+			service := &testBesuService{
+				header:     header.Base,
+				validators: validators,
+				votes:      map[common.Address]bool{candidate: true},
+			}
+			server := rpc.NewServer()
+			require.NoError(t, server.RegisterName("eth", &testBesuEthService{service}))
+			require.NoError(t, server.RegisterName(string(consensus), service))
+			defer server.Stop()
+			cl, err := NewBesuClient(NewETHClientFromRPCClient(rpc.DialInProc(server)), consensus)
+			require.NoError(t, err)
+			ctx := context.Background()
+
+			// 1. The consensus APIs are called with the prefix of the consensus type
+			vals, err := cl.GetValidatorsByBlockNumber(ctx, big.NewInt(10))
+			require.NoError(t, err)
+			require.Equal(t, validators, vals)
+			votes, err := cl.GetPendingVotes(ctx)
+			require.NoError(t, err)
+			require.Equal(t, map[common.Address]chains.VoteType{candidate: chains.VoteAdd}, votes)
+			metrics, err := cl.GetSignerMetrics(ctx, big.NewInt(1), nil)
+			require.NoError(t, err)
+			require.Equal(t, []SignerMetric{{Address: validators[0], ProposedBlockCount: 10, LastProposedBlockNumber: 10}}, metrics)
+			metrics, err = cl.GetSignerMetrics(ctx, big.NewInt(3), big.NewInt(8))
+			require.NoError(t, err)
+			require.Equal(t, []SignerMetric{{Address: validators[0], ProposedBlockCount: 6, LastProposedBlockNumber: 8}}, metrics)
+			_, err = cl.GetSignerMetrics(ctx, nil, big.NewInt(8))
+			require.Error(t, err)
+
+			// 2. The validators are consistent with the header
+			require.NoError(t, cl.CheckValidators(ctx, nil))
+
+			// 3. The node returns a different validator set
+			service.validators = append(validators[:3:3], candidate)
+			err = cl.CheckValidators(ctx, big.NewInt(10))
+			var mismatch *ValidatorSetMismatchError
+			require.True(t, errors.As(err, &mismatch))
+			require.Equal(t, uint64(10), mismatch.Height)
+			require.Equal(t, validators, mismatch.Header)
+		})
+	}
+
+	_, err := NewBesuClient(nil, "clique")
+	require.Error(t, err)
+}
+
+type testBesuService struct {
+	header     *gethtypes.Header
+	validators []common.Address
+	votes      map[common.Address]bool
+}
+
+func (s *testBesuService) GetValidatorsByBlockNumber(ctx context.Context, number rpc.BlockNumber) ([]common.Address, error) {
+	if number.Int64() != s.header.Number.Int64() {
+		return nil, errors.New("unexpected block number")
+	}
+	return s.validators, nil
+}
+
+func (s *testBesuService) GetPendingVotes(ctx context.Context) (map[common.Address]bool, error) {
+	return s.votes, nil
+}
+
+// GetSignerMetrics returns the metrics as if validators[0] proposed all the blocks in the range. The omitted `to` means the latest block.
+func (s *testBesuService) GetSignerMetrics(ctx context.Context, from rpc.BlockNumber, to *rpc.BlockNumber) ([]SignerMetric, error) {
+	last := s.header.Number.Uint64()
+	if to != nil {
+		if *to < 0 {
+			return nil, errors.New("unexpected block number")
+		}
+		last = uint64(*to)
+	}
+	return []SignerMetric{{Address: s.validators[0], ProposedBlockCount: hexutil.Uint64(last - uint64(from) + 1), LastProposedBlockNumber: hexutil.Uint64(last)}}, nil
+}
+
+type testBesuEthService struct {
+	*testBesuService
+}
+
+func (s *testBesuEthService) BlockNumber(ctx context.Context) (hexutil.Uint64, error) {
+	return hexutil.Uint64(s.header.Number.Uint64()), nil
+}
+
+// GetBlockByNumber returns the header only for its number, so the latest block must be resolved to a height first.
+func (s *testBesuEthService) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool) (*gethtypes.Header, error) {
+	if number.Int64() != s.header.Number.Int64() {
+		return nil, errors.New("unexpected block number")
+	}
+	return s.header, nil
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BlockRef returns a reference to the block of the number. If the number is nil, it refers to the latest block.
+func BlockRef(number *big.Int) rpc.BlockNumberOrHash {
+	if number == nil {
+		return rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	}
+	return rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(number.Int64()))
+}
+
+// blockArg returns the block parameter of EIP-1898 for the reference.
+// A number or tag(e.g. "latest", "safe" and "finalized") is encoded as a string and a hash is encoded as an object.
+func blockArg(ref rpc.BlockNumberOrHash) (interface{}, error) {
+	if hash, ok := ref.Hash(); ok {
+		return map[string]interface{}{
+			"blockHash":        hash,
+			"requireCanonical": ref.RequireCanonical,
+		}, nil
+	}
+	if number, ok := ref.Number(); ok {
+		return number, nil
+	}
+	return nil, fmt.Errorf("invalid block reference: %v", ref.String())
+}
+
+// HeaderByRef returns the header of the block referred by a number, a tag or a hash.
+func (cl *ETHClient) HeaderByRef(ctx context.Context, ref rpc.BlockNumberOrHash) (*gethtypes.Header, error) {
+	header, _, err := cl.HeaderAndHashByRef(ctx, ref)
+	return header, err
+}
+
+// HeaderAndHashByRef returns the header and the block hash returned by the node.
+// The hash may differ from `header.Hash()` on chains like Besu IBFT2 whose block hash excludes a part of the extra data.
+func (cl *ETHClient) HeaderAndHashByRef(ctx context.Context, ref rpc.BlockNumberOrHash) (*gethtypes.Header, common.Hash, error) {
+	var raw json.RawMessage
+	var err error
+	if hash, ok := ref.Hash(); ok {
+		err = cl.rpcClient.CallContext(ctx, &raw, "eth_getBlockByHash", hash, false)
+	} else if number, ok := ref.Number(); ok {
+		err = cl.rpcClient.CallContext(ctx, &raw, "eth_getBlockByNumber", number, false)
+	} else {
+		return nil, common.Hash{}, fmt.Errorf("invalid block reference: %v", ref.String())
+	}
+	if err != nil {
+		return nil, common.Hash{}, err
+	} else if len(raw) == 0 || string(raw) == "null" {
+		return nil, common.Hash{}, ethereum.NotFound
+	}
+	var header gethtypes.Header
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, common.Hash{}, err
+	}
+	var block struct {
+		Hash common.Hash `json:"hash"`
+	}
+	if err := json.Unmarshal(raw, &block); err != nil {
+		return nil, common.Hash{}, err
+	}
+	return &header, block.Hash, nil
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockArg(t *testing.T) {
+	hash := common.HexToHash("0x01")
+	for _, c := range []struct {
+		ref      rpc.BlockNumberOrHash
+		expected string
+	}{
+		{BlockRef(nil), `"latest"`},
+		{BlockRef(big.NewInt(16)), `"0x10"`},
+		{rpc.BlockNumberOrHashWithNumber(rpc.SafeBlockNumber), `"safe"`},
+		{rpc.BlockNumberOrHashWithNumber(rpc.FinalizedBlockNumber), `"finalized"`},
+		{rpc.BlockNumberOrHashWithHash(hash, true), `{"blockHash":"` + hash.Hex() + `","requireCanonical":true}`},
+	} {
+		arg, err := blockArg(c.ref)
+		require.NoError(t, err)
+		bz, err := json.Marshal(arg)
+		require.NoError(t, err)
+		require.Equal(t, c.expected, string(bz))
+	}
+	_, err := blockArg(rpc.BlockNumberOrHash{})
+	require.Error(t, err)
+}
+
+func TestHeaderAndHashByRef(t *testing.T) {
+	service := &testEthService{number: 3}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	defer server.Stop()
+	cl := NewETHClientFromRPCClient(rpc.DialInProc(server))
+
+	header, hash, err := cl.HeaderAndHashByRef(context.Background(), BlockRef(big.NewInt(2)))
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(2), header.Number)
+	require.Equal(t, header.Hash(), hash)
+
+	_, _, err = cl.HeaderAndHashByRef(context.Background(), BlockRef(big.NewInt(4)))
+	require.ErrorIs(t, err, ethereum.NotFound)
+}
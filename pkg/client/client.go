@@ -2,13 +2,15 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"math/big"
+	"net/http"
 	"time"
 
 	"github.com/avast/retry-go"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -18,12 +20,32 @@ type ETHClient struct {
 	*ethclient.Client
 	rpcClient *rpc.Client
 	option    option
+	// pool is set if the client has multiple endpoints. See NewMultiETHClient.
+	pool *endpointPool
 }
 
 type Option func(*option)
 
 type option struct {
 	retryOpts []retry.Option
+	headers   http.Header
+	// pollInterval is used to poll new heads if the endpoint doesn't support subscriptions
+	pollInterval time.Duration
+	// receiptWaitBlocks is the number of new heads to wait for a receipt if the endpoint supports subscriptions
+	receiptWaitBlocks uint64
+	errorDecoder      *ErrorDecoder
+	// replayRevert enables to recover the revert reason of a failed transaction if the node doesn't return it in the receipt
+	replayRevert bool
+	// healthCheckInterval is the interval to check the health of the endpoints of a multi-endpoint client
+	healthCheckInterval time.Duration
+	// maxHeadLag is the number of blocks an endpoint of a multi-endpoint client can lag behind the highest head before it is considered unhealthy
+	maxHeadLag uint64
+	// confirmations is the number of blocks built on top of the block including a transaction to wait for
+	confirmations uint64
+	// maxProofKeys is the maximum number of storage keys in one `eth_getProof` call of GetProofBatch
+	maxProofKeys int
+	// maxPollFailures is the number of consecutive failed polls that ends a polling subscription
+	maxPollFailures int
 }
 
 func DefaultOption() *option {
@@ -32,6 +54,16 @@ func DefaultOption() *option {
 			retry.Delay(1 * time.Second),
 			retry.Attempts(10),
 		},
+		headers:           make(http.Header),
+		pollInterval:      1 * time.Second,
+		receiptWaitBlocks: 10,
+		errorDecoder:      DefaultErrorDecoder(),
+		replayRevert:      true,
+
+		healthCheckInterval: 5 * time.Second,
+		maxHeadLag:          5,
+		maxProofKeys:        100,
+		maxPollFailures:     5,
 	}
 }
 
@@ -41,15 +73,76 @@ func WithRetryOption(rops ...retry.Option) Option {
 	}
 }
 
+// WithHeader adds a header to each request (e.g. Authorization). This has no effect on the IPC transport.
+func WithHeader(key, value string) Option {
+	return func(opt *option) {
+		opt.headers.Add(key, value)
+	}
+}
+
+func WithPollInterval(interval time.Duration) Option {
+	return func(opt *option) {
+		opt.pollInterval = interval
+	}
+}
+
+func WithReceiptWaitBlocks(blocks uint64) Option {
+	return func(opt *option) {
+		opt.receiptWaitBlocks = blocks
+	}
+}
+
+// WithErrorDecoder sets a decoder for the revert reasons of failed transactions
+func WithErrorDecoder(decoder *ErrorDecoder) Option {
+	return func(opt *option) {
+		opt.errorDecoder = decoder
+	}
+}
+
+// WithRevertReplay enables or disables to recover revert reasons by replaying failed transactions. See ETHClient.ReplayRevertData.
+func WithRevertReplay(enabled bool) Option {
+	return func(opt *option) {
+		opt.replayRevert = enabled
+	}
+}
+
+// WithConfirmations sets the number of blocks to wait for after a transaction is included. See ETHClient.WaitForConfirmations.
+func WithConfirmations(n uint64) Option {
+	return func(opt *option) {
+		opt.confirmations = n
+	}
+}
+
+// WithMaxProofKeys sets the maximum number of storage keys in one `eth_getProof` call. See ETHClient.GetProofBatch.
+func WithMaxProofKeys(n int) Option {
+	return func(opt *option) {
+		opt.maxProofKeys = n
+	}
+}
+
+// WithMaxPollFailures sets the number of consecutive failed polls that ends a polling subscription. See ETHClient.WatchNewHeads.
+func WithMaxPollFailures(n int) Option {
+	return func(opt *option) {
+		opt.maxPollFailures = n
+	}
+}
+
+// WithHealthCheck configures the health checks of a multi-endpoint client. See NewMultiETHClient.
+func WithHealthCheck(interval time.Duration, maxHeadLag uint64) Option {
+	return func(opt *option) {
+		opt.healthCheckInterval = interval
+		opt.maxHeadLag = maxHeadLag
+	}
+}
+
+// NewETHClient creates a new client for the endpoint.
+// The transport is selected from the URL scheme: "http(s)://", "ws(s)://" or a path to the IPC socket.
 func NewETHClient(endpoint string, opts ...Option) (*ETHClient, error) {
-	rpcClient, err := rpc.DialHTTP(endpoint)
+	opt := makeOption(opts...)
+	rpcClient, err := rpc.DialOptions(context.Background(), endpoint, rpc.WithHeaders(opt.headers))
 	if err != nil {
 		return nil, err
 	}
-	opt := DefaultOption()
-	for _, o := range opts {
-		o(opt)
-	}
 	return &ETHClient{
 		rpcClient: rpcClient,
 		Client:    ethclient.NewClient(rpcClient),
@@ -57,24 +150,94 @@ func NewETHClient(endpoint string, opts ...Option) (*ETHClient, error) {
 	}, nil
 }
 
+// NewETHClientFromRPCClient creates a new client with the given rpc client (e.g. an in-process client created by rpc.DialInProc).
+func NewETHClientFromRPCClient(rpcClient *rpc.Client, opts ...Option) *ETHClient {
+	opt := makeOption(opts...)
+	for key, values := range opt.headers {
+		for _, v := range values {
+			rpcClient.SetHeader(key, v)
+		}
+	}
+	return &ETHClient{
+		rpcClient: rpcClient,
+		Client:    ethclient.NewClient(rpcClient),
+		option:    *opt,
+	}
+}
+
+func makeOption(opts ...Option) *option {
+	opt := DefaultOption()
+	for _, o := range opts {
+		o(opt)
+	}
+	return opt
+}
+
 func (cl *ETHClient) GetTransactionReceipt(ctx context.Context, txHash common.Hash) (rc *gethtypes.Receipt, recoverable bool, err error) {
 	var r *Receipt
 	if err := cl.rpcClient.CallContext(ctx, &r, "eth_getTransactionReceipt", txHash); err != nil {
-		return &r.Receipt, true, err
+		return nil, true, err
 	}
 	if r == nil {
 		return nil, true, ethereum.NotFound
 	} else if r.Status == gethtypes.ReceiptStatusSuccessful {
 		return &r.Receipt, false, nil
-	} else if r.HasRevertReason() {
-		reason, err := r.GetRevertReason()
-		return &r.Receipt, false, fmt.Errorf("revert: %v(parse-err=%v)", reason, err)
+	}
+	if !r.HasRevertReason() && cl.option.replayRevert {
+		data, err := cl.ReplayRevertData(ctx, &r.Receipt)
+		if err != nil {
+			return &r.Receipt, false, fmt.Errorf("failed to execute a transaction: %v(replay-err=%v)", r, err)
+		}
+		r.RevertReason = data
+	}
+	if r.HasRevertReason() {
+		return &r.Receipt, false, cl.revertError(r.RevertReason)
 	} else {
 		return &r.Receipt, false, fmt.Errorf("failed to execute a transaction: %v", r)
 	}
 }
 
+// WaitForReceiptAndGet waits for the receipt of the transaction.
+// If the endpoint supports subscriptions, the receipt is checked on each new head. Otherwise, it is polled with the retry options.
+// If the confirmations are configured with WithConfirmations, it also waits for them.
 func (cl *ETHClient) WaitForReceiptAndGet(ctx context.Context, tx *gethtypes.Transaction) (*gethtypes.Receipt, error) {
+	rc, err := cl.waitForReceiptAndGet(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	return cl.WaitForConfirmations(ctx, rc, cl.option.confirmations)
+}
+
+func (cl *ETHClient) waitForReceiptAndGet(ctx context.Context, tx *gethtypes.Transaction) (*gethtypes.Receipt, error) {
+	heads := make(chan *gethtypes.Header, 1)
+	sub, err := cl.Client.SubscribeNewHead(ctx, heads)
+	if err == rpc.ErrNotificationsUnsupported {
+		return cl.pollReceiptAndGet(ctx, tx)
+	} else if err != nil {
+		return nil, err
+	}
+	defer sub.Unsubscribe()
+
+	var blocks uint64
+	for {
+		rc, recoverable, err := cl.GetTransactionReceipt(ctx, tx.Hash())
+		if err == nil {
+			return rc, nil
+		} else if !recoverable || blocks >= cl.option.receiptWaitBlocks {
+			return nil, err
+		}
+		select {
+		case <-heads:
+			blocks++
+		case err := <-sub.Err():
+			return nil, err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (cl *ETHClient) pollReceiptAndGet(ctx context.Context, tx *gethtypes.Transaction) (*gethtypes.Receipt, error) {
 	var receipt *gethtypes.Receipt
 	err := retry.Do(
 		func() error {
@@ -99,7 +262,23 @@ func (cl *ETHClient) WaitForReceiptAndGet(ctx context.Context, tx *gethtypes.Tra
 
 type Receipt struct {
 	gethtypes.Receipt
-	RevertReason []byte `json:"revertReason,omitempty"`
+	RevertReason hexutil.Bytes `json:"revertReason,omitempty"`
+}
+
+// UnmarshalJSON decodes a receipt with the revertReason field.
+// NOTE: this is required because gethtypes.Receipt.UnmarshalJSON is promoted and ignores the other fields.
+func (rc *Receipt) UnmarshalJSON(bz []byte) error {
+	if err := rc.Receipt.UnmarshalJSON(bz); err != nil {
+		return err
+	}
+	var ext struct {
+		RevertReason hexutil.Bytes `json:"revertReason,omitempty"`
+	}
+	if err := json.Unmarshal(bz, &ext); err != nil {
+		return err
+	}
+	rc.RevertReason = ext.RevertReason
+	return nil
 }
 
 func (rc Receipt) HasRevertReason() bool {
@@ -109,20 +288,3 @@ func (rc Receipt) HasRevertReason() bool {
 func (rc Receipt) GetRevertReason() (string, error) {
 	return parseRevertReason(rc.RevertReason)
 }
-
-// A format of revertReason is:
-// 4byte: Function selector for Error(string)
-// 32byte: Data offset
-// 32byte: String length
-// Remains: String Data
-func parseRevertReason(bz []byte) (string, error) {
-	if l := len(bz); l == 0 {
-		return "", nil
-	} else if l < 68 {
-		return "", fmt.Errorf("invalid length")
-	}
-
-	size := &big.Int{}
-	size.SetBytes(bz[36:68])
-	return string(bz[68 : 68+size.Int64()]), nil
-}
@@ -2,9 +2,13 @@ package client
 
 import (
 	"encoding/hex"
+	"encoding/json"
+	"math/big"
 	"strings"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -24,6 +28,56 @@ func TestRevertReasonParser(t *testing.T) {
 	// 3. Invalid format
 	_, err = parseRevertReason([]byte{0})
 	require.Error(t, err)
+
+	// 4. String length exceeds the data
+	_, err = parseRevertReason(
+		hexToBytes("0x08c379a00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000ffff4e6f7420656e6f7567682045746865722070726f76696465642e000000000000"),
+	)
+	require.Error(t, err)
+}
+
+func TestErrorDecoder(t *testing.T) {
+	customABI, err := abi.JSON(strings.NewReader(`[{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}]`))
+	require.NoError(t, err)
+	decoder := NewErrorDecoder(customABI)
+
+	// 1. Panic(uint256)
+	reason, err := decoder.Decode(hexToBytes("0x4e487b710000000000000000000000000000000000000000000000000000000000000011"))
+	require.NoError(t, err)
+	require.IsType(t, PanicReason{}, reason)
+	require.Equal(t, "arithmetic underflow or overflow", reason.(PanicReason).Description())
+
+	// 2. Custom error
+	customErr := customABI.Errors["InsufficientBalance"]
+	args, err := customErr.Inputs.Pack(big.NewInt(1), big.NewInt(2))
+	require.NoError(t, err)
+	reason, err = decoder.Decode(append(customErr.ID[:4], args...))
+	require.NoError(t, err)
+	require.IsType(t, CustomErrorReason{}, reason)
+	require.Equal(t, "InsufficientBalance(1, 2)", reason.String())
+
+	// 3. Malformed data
+	for _, data := range [][]byte{
+		nil,
+		hexToBytes("0x4e487b71"),
+		hexToBytes("0x08c379a0"),
+		append(customErr.ID[:4], args[:40]...),
+		hexToBytes("0xdeadbeef"),
+	} {
+		_, err := decoder.Decode(data)
+		require.Error(t, err)
+	}
+}
+
+func TestReceiptRevertReason(t *testing.T) {
+	bz := []byte(`{"status":"0x0","cumulativeGasUsed":"0x1","logsBloom":"0x` + strings.Repeat("0", 512) + `","logs":[],"transactionHash":"0x0000000000000000000000000000000000000000000000000000000000000001","gasUsed":"0x1","revertReason":"0x4e487b710000000000000000000000000000000000000000000000000000000000000001"}`)
+	var rc Receipt
+	require.NoError(t, json.Unmarshal(bz, &rc))
+	require.Equal(t, gethtypes.ReceiptStatusFailed, rc.Status)
+	require.True(t, rc.HasRevertReason())
+	reason, err := rc.GetRevertReason()
+	require.NoError(t, err)
+	require.Equal(t, "panic: assertion failed (0x1)", reason)
 }
 
 func hexToBytes(s string) []byte {
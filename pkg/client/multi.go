@@ -0,0 +1,357 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxPinnedHeights is the maximum number of heights pinned to endpoints
+const maxPinnedHeights = 1024
+
+// NewMultiETHClient creates a client that sends each request to the healthiest of the endpoints and fails over to the others on transport errors.
+// All endpoints must be HTTP endpoints of the same chain: it returns an error if they disagree on the chain ID or the block hash at the lowest head.
+// Requests with a context created by WithPinnedHeight are always sent to the same endpoint for the height so that headers and proofs stay consistent.
+// The health of the endpoints is checked in the background until Close is called.
+func NewMultiETHClient(ctx context.Context, endpoints []string, opts ...Option) (*ETHClient, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("no endpoints")
+	}
+	opt := makeOption(opts...)
+	pool, err := newEndpointPool(ctx, endpoints, opt)
+	if err != nil {
+		return nil, err
+	}
+	rpcClient, err := rpc.DialOptions(
+		ctx,
+		endpoints[0],
+		rpc.WithHTTPClient(&http.Client{Transport: pool}),
+		rpc.WithHeaders(opt.headers),
+	)
+	if err != nil {
+		pool.close()
+		return nil, err
+	}
+	go pool.run(opt.healthCheckInterval)
+	return &ETHClient{
+		rpcClient: rpcClient,
+		Client:    ethclient.NewClient(rpcClient),
+		option:    *opt,
+		pool:      pool,
+	}, nil
+}
+
+// Close closes the connection and stops the health checks of the endpoints.
+func (cl *ETHClient) Close() {
+	if cl.pool != nil {
+		cl.pool.close()
+	}
+	cl.Client.Close()
+}
+
+// Endpoints returns the status of each endpoint. It returns nil if the client has a single endpoint.
+func (cl *ETHClient) Endpoints() []EndpointStatus {
+	if cl.pool == nil {
+		return nil
+	}
+	return cl.pool.status()
+}
+
+type pinnedHeightKey struct{}
+
+// WithPinnedHeight returns a context that pins requests to an endpoint for the height.
+// It has no effect if the client has a single endpoint.
+func WithPinnedHeight(ctx context.Context, height *big.Int) context.Context {
+	if height == nil || !height.IsUint64() {
+		return ctx
+	}
+	return context.WithValue(ctx, pinnedHeightKey{}, height.Uint64())
+}
+
+// EndpointStatus is the status of an endpoint observed by the last health check or request
+type EndpointStatus struct {
+	URL     string
+	Healthy bool
+	Head    uint64
+	Latency time.Duration
+	LastErr error
+}
+
+type endpoint struct {
+	url       *url.URL
+	rpcClient *rpc.Client
+	EndpointStatus
+}
+
+type endpointPool struct {
+	chainID *big.Int
+	maxLag  uint64
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+	pins      map[uint64]*endpoint
+
+	transport http.RoundTripper
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+var _ http.RoundTripper = (*endpointPool)(nil)
+
+func newEndpointPool(ctx context.Context, urls []string, opt *option) (*endpointPool, error) {
+	pool := &endpointPool{
+		maxLag:    opt.maxHeadLag,
+		pins:      make(map[uint64]*endpoint),
+		transport: http.DefaultTransport,
+		stop:      make(chan struct{}),
+	}
+	for _, s := range urls {
+		u, err := url.Parse(s)
+		if err != nil {
+			pool.close()
+			return nil, err
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			pool.close()
+			return nil, fmt.Errorf("only HTTP endpoints are supported: %v", s)
+		}
+		rpcClient, err := rpc.DialOptions(ctx, s, rpc.WithHeaders(opt.headers))
+		if err != nil {
+			pool.close()
+			return nil, err
+		}
+		pool.endpoints = append(pool.endpoints, &endpoint{url: u, rpcClient: rpcClient, EndpointStatus: EndpointStatus{URL: s}})
+	}
+	if err := pool.checkConsistency(ctx); err != nil {
+		pool.close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// checkConsistency checks that all endpoints agree on the chain ID and the block hash at the lowest head.
+func (p *endpointPool) checkConsistency(ctx context.Context) error {
+	var minHead uint64
+	for i, ep := range p.endpoints {
+		var chainID hexutil.Big
+		if err := ep.rpcClient.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+			return fmt.Errorf("failed to get the chain ID: endpoint=%v err=%v", ep.URL, err)
+		}
+		if p.chainID == nil {
+			p.chainID = chainID.ToInt()
+		} else if p.chainID.Cmp(chainID.ToInt()) != 0 {
+			return fmt.Errorf("chain ID mismatch: endpoint=%v expected=%v actual=%v", ep.URL, p.chainID, chainID.ToInt())
+		}
+		if err := p.checkHealth(ctx, ep); err != nil {
+			return err
+		}
+		if i == 0 || ep.Head < minHead {
+			minHead = ep.Head
+		}
+	}
+	var hash *common.Hash
+	for _, ep := range p.endpoints {
+		var block struct {
+			Hash common.Hash `json:"hash"`
+		}
+		if err := ep.rpcClient.CallContext(ctx, &block, "eth_getBlockByNumber", hexutil.EncodeUint64(minHead), false); err != nil {
+			return fmt.Errorf("failed to get the block: endpoint=%v number=%v err=%v", ep.URL, minHead, err)
+		}
+		if hash == nil {
+			hash = &block.Hash
+		} else if *hash != block.Hash {
+			return fmt.Errorf("block hash mismatch: endpoint=%v number=%v expected=%v actual=%v", ep.URL, minHead, *hash, block.Hash)
+		}
+	}
+	p.updateHealth()
+	return nil
+}
+
+// checkHealth queries the head of the endpoint and updates its status
+func (p *endpointPool) checkHealth(ctx context.Context, ep *endpoint) error {
+	start := time.Now()
+	var head hexutil.Uint64
+	err := ep.rpcClient.CallContext(ctx, &head, "eth_blockNumber")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ep.LastErr = err
+	if err != nil {
+		ep.Healthy = false
+		return fmt.Errorf("failed to get the head: endpoint=%v err=%v", ep.URL, err)
+	}
+	ep.Healthy = true
+	ep.Head = uint64(head)
+	ep.Latency = time.Since(start)
+	return nil
+}
+
+// updateHealth marks the endpoints lagging behind the highest head by more than maxLag as unhealthy
+func (p *endpointPool) updateHealth() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var maxHead uint64
+	for _, ep := range p.endpoints {
+		if ep.LastErr == nil && ep.Head > maxHead {
+			maxHead = ep.Head
+		}
+	}
+	for _, ep := range p.endpoints {
+		if ep.LastErr == nil {
+			ep.Healthy = ep.Head+p.maxLag >= maxHead
+		}
+	}
+}
+
+func (p *endpointPool) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			for _, ep := range p.endpoints {
+				_ = p.checkHealth(ctx, ep)
+			}
+			cancel()
+			p.updateHealth()
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+func (p *endpointPool) close() {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+		for _, ep := range p.endpoints {
+			ep.rpcClient.Close()
+		}
+	})
+}
+
+func (p *endpointPool) status() []EndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var status []EndpointStatus
+	for _, ep := range p.endpoints {
+		status = append(status, ep.EndpointStatus)
+	}
+	return status
+}
+
+// candidates returns the endpoints in order of preference: healthy endpoints first, then higher heads and lower latencies.
+func (p *endpointPool) candidates() []*endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	eps := append([]*endpoint{}, p.endpoints...)
+	sort.SliceStable(eps, func(i, j int) bool {
+		a, b := eps[i], eps[j]
+		if a.Healthy != b.Healthy {
+			return a.Healthy
+		} else if a.Head != b.Head {
+			return a.Head > b.Head
+		}
+		return a.Latency < b.Latency
+	})
+	return eps
+}
+
+// pinned returns the endpoint pinned for the height. If no endpoint is pinned, the most preferred one is pinned.
+func (p *endpointPool) pinned(height uint64) *endpoint {
+	eps := p.candidates()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ep, ok := p.pins[height]; ok {
+		return ep
+	}
+	if len(p.pins) >= maxPinnedHeights {
+		for h := range p.pins {
+			if h+maxPinnedHeights <= height || h > height {
+				delete(p.pins, h)
+			}
+		}
+	}
+	p.pins[height] = eps[0]
+	return eps[0]
+}
+
+func (p *endpointPool) unpin(height uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pins, height)
+}
+
+func (p *endpointPool) markFailure(ep *endpoint, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ep.Healthy = false
+	ep.LastErr = err
+}
+
+// RoundTrip sends the request to the endpoints in order of preference until one of them responds without a transport error.
+// A pinned request is sent only to the pinned endpoint. If it fails, the pin is dropped and the error is returned.
+func (p *endpointPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		bz, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = bz
+	}
+	if height, ok := req.Context().Value(pinnedHeightKey{}).(uint64); ok {
+		ep := p.pinned(height)
+		res, err := p.send(req, body, ep)
+		if err != nil {
+			p.unpin(height)
+			return nil, err
+		}
+		return res, nil
+	}
+	var errs []error
+	for _, ep := range p.candidates() {
+		res, err := p.send(req, body, ep)
+		if err == nil {
+			return res, nil
+		}
+		errs = append(errs, err)
+		if req.Context().Err() != nil {
+			break
+		}
+	}
+	return nil, fmt.Errorf("all endpoints failed: %v", errs)
+}
+
+func (p *endpointPool) send(req *http.Request, body []byte, ep *endpoint) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	u := *ep.url
+	r.URL = &u
+	r.Host = ""
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	res, err := p.transport.RoundTrip(r)
+	if err != nil {
+		err = fmt.Errorf("endpoint=%v err=%w", ep.URL, err)
+		p.markFailure(ep, err)
+		return nil, err
+	} else if res.StatusCode >= http.StatusInternalServerError {
+		res.Body.Close()
+		err = fmt.Errorf("endpoint=%v status=%v", ep.URL, res.Status)
+		p.markFailure(ep, err)
+		return nil, err
+	}
+	return res, nil
+}
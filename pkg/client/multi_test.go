@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiETHClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	newServer := func(service *testEthService) *httptest.Server {
+		server := rpc.NewServer()
+		require.NoError(t, server.RegisterName("eth", service))
+		t.Cleanup(server.Stop)
+		httpServer := httptest.NewServer(server)
+		t.Cleanup(httpServer.Close)
+		return httpServer
+	}
+	serverA := newServer(&testEthService{number: 3})
+	serverB := newServer(&testEthService{number: 2})
+
+	// 1. Endpoints of different chains are rejected
+	_, err := NewMultiETHClient(ctx, []string{serverA.URL, newServer(&testEthService{number: 3, chainID: 1}).URL})
+	require.Error(t, err)
+
+	cl, err := NewMultiETHClient(ctx, []string{serverB.URL, serverA.URL}, WithHealthCheck(time.Hour, 5))
+	require.NoError(t, err)
+	defer cl.Close()
+
+	// 2. The endpoint with the highest head is preferred
+	number, err := cl.BlockNumber(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), number)
+
+	// 3. A height is pinned to the endpoint
+	pinned := WithPinnedHeight(ctx, big.NewInt(2))
+	header, err := cl.HeaderByNumber(pinned, big.NewInt(2))
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), header.Number.Uint64())
+
+	// 4. Fail over to the other endpoint
+	serverA.Close()
+	number, err = cl.BlockNumber(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), number)
+	status := cl.Endpoints()
+	require.True(t, status[0].Healthy)
+	require.False(t, status[1].Healthy)
+
+	// 5. A pinned request doesn't fail over, but the height is pinned again to a healthy endpoint on the next request
+	_, err = cl.HeaderByNumber(pinned, big.NewInt(2))
+	require.Error(t, err)
+	header, err = cl.HeaderByNumber(pinned, big.NewInt(2))
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), header.Number.Uint64())
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 type StateProof struct {
@@ -19,13 +21,113 @@ type StateProof struct {
 
 	AccountProofRLP []byte
 	StorageProofRLP [][]byte
+	StorageProofs   []StorageProof
 }
 
+// StorageProof is a proof of a storage slot with the value returned by `eth_getProof`.
+// If the slot is empty, `Exists` is false and `ProofRLP` is a proof of non-existence.
+type StorageProof struct {
+	Key      common.Hash
+	Value    common.Hash
+	Exists   bool
+	ProofRLP []byte
+}
+
+// GetStorageProof returns the storage proof corresponding to `key`.
+func (sp StateProof) GetStorageProof(key common.Hash) (*StorageProof, bool) {
+	for i := range sp.StorageProofs {
+		if sp.StorageProofs[i].Key == key {
+			return &sp.StorageProofs[i], true
+		}
+	}
+	return nil, false
+}
+
+// GetProof returns the proof at the block of the number. If the number is nil, it returns the proof at the latest block.
 func (cl ETHClient) GetProof(address common.Address, storageKeys [][]byte, blockNumber *big.Int) (*StateProof, error) {
-	bz, err := cl.getProof(address, storageKeys, "0x"+blockNumber.Text(16))
+	return cl.GetProofAt(context.Background(), address, storageKeys, BlockRef(blockNumber))
+}
+
+// GetProofAt returns the proof at the block referred by a number, a tag("latest", "safe" or "finalized") or a hash(EIP-1898).
+func (cl ETHClient) GetProofAt(ctx context.Context, address common.Address, storageKeys [][]byte, block rpc.BlockNumberOrHash) (*StateProof, error) {
+	if number, ok := block.Number(); ok && number >= 0 {
+		// the proof is pinned to the endpoint that serves the header at the height if the client has multiple endpoints
+		ctx = WithPinnedHeight(ctx, big.NewInt(number.Int64()))
+	}
+	arg, err := blockArg(block)
+	if err != nil {
+		return nil, err
+	}
+	bz, err := cl.getProof(ctx, address, storageKeys, arg)
+	if err != nil {
+		return nil, err
+	}
+	return parseStateProof(bz)
+}
+
+// GetProofBatch returns the proof of many storage keys at the same block.
+// The keys are split into chunks of the size configured by WithMaxProofKeys. If there are multiple chunks, they are queried by a JSON-RPC batch.
+// A tag is resolved to a height first so that all chunks are queried at the same block.
+func (cl ETHClient) GetProofBatch(ctx context.Context, address common.Address, storageKeys [][]byte, block rpc.BlockNumberOrHash) (*StateProof, error) {
+	max := cl.option.maxProofKeys
+	if max <= 0 || len(storageKeys) <= max {
+		return cl.GetProofAt(ctx, address, storageKeys, block)
+	}
+	if number, ok := block.Number(); ok && number < 0 {
+		header, err := cl.HeaderByRef(ctx, block)
+		if err != nil {
+			return nil, err
+		}
+		block = BlockRef(header.Number)
+	}
+	if number, ok := block.Number(); ok {
+		ctx = WithPinnedHeight(ctx, big.NewInt(number.Int64()))
+	}
+	arg, err := blockArg(block)
 	if err != nil {
 		return nil, err
 	}
+	var batch []rpc.BatchElem
+	for i := 0; i < len(storageKeys); i += max {
+		end := i + max
+		if end > len(storageKeys) {
+			end = len(storageKeys)
+		}
+		hashes, err := toStorageHashes(storageKeys[i:end])
+		if err != nil {
+			return nil, err
+		}
+		batch = append(batch, rpc.BatchElem{
+			Method: "eth_getProof",
+			Args:   []interface{}{address, hashes, arg},
+			Result: new(json.RawMessage),
+		})
+	}
+	if err := cl.rpcClient.BatchCallContext(ctx, batch); err != nil {
+		return nil, err
+	}
+	var merged *StateProof
+	for i, elem := range batch {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("failed to get the proof of the chunk %v: %v", i, elem.Error)
+		}
+		proof, err := parseStateProof(*elem.Result.(*json.RawMessage))
+		if err != nil {
+			return nil, err
+		}
+		if merged == nil {
+			merged = proof
+			continue
+		} else if merged.StorageHash != proof.StorageHash {
+			return nil, fmt.Errorf("storage hash mismatch between chunks: expected=%x actual=%x", merged.StorageHash, proof.StorageHash)
+		}
+		merged.StorageProofRLP = append(merged.StorageProofRLP, proof.StorageProofRLP...)
+		merged.StorageProofs = append(merged.StorageProofs, proof.StorageProofs...)
+	}
+	return merged, nil
+}
+
+func parseStateProof(bz []byte) (*StateProof, error) {
 	var proof struct {
 		Balance      string   `json:"balance"`
 		CodeHash     string   `json:"codeHash"`
@@ -33,6 +135,8 @@ func (cl ETHClient) GetProof(address common.Address, storageKeys [][]byte, block
 		StorageHash  string   `json:"storageHash"`
 		AccountProof []string `json:"accountProof"`
 		StorageProof []struct {
+			Key   string   `json:"key"`
+			Value string   `json:"value"`
 			Proof []string `json:"proof"`
 		} `json:"storageProof"`
 	}
@@ -73,6 +177,7 @@ func (cl ETHClient) GetProof(address common.Address, storageKeys [][]byte, block
 		copy(storageHash[:], bz)
 	}
 
+	var err error
 	var encodedProof = StateProof{
 		Balance:     balance,
 		CodeHash:    codeHash,
@@ -88,12 +193,30 @@ func (cl ETHClient) GetProof(address common.Address, storageKeys [][]byte, block
 		if err != nil {
 			return nil, err
 		}
+		sp, err := makeStorageProof(p.Key, p.Value, bz)
+		if err != nil {
+			return nil, err
+		}
 		encodedProof.StorageProofRLP = append(encodedProof.StorageProofRLP, bz)
+		encodedProof.StorageProofs = append(encodedProof.StorageProofs, *sp)
 	}
 	return &encodedProof, nil
 }
 
-func (cl ETHClient) getProof(address common.Address, storageKeys [][]byte, blockNumber string) ([]byte, error) {
+func (cl ETHClient) getProof(ctx context.Context, address common.Address, storageKeys [][]byte, block interface{}) ([]byte, error) {
+	hashes, err := toStorageHashes(storageKeys)
+	if err != nil {
+		return nil, err
+	}
+	var msg json.RawMessage
+	if err := cl.rpcClient.CallContext(ctx, &msg, "eth_getProof", address, hashes, block); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// toStorageHashes converts hex strings of storage keys into hashes
+func toStorageHashes(storageKeys [][]byte) ([]common.Hash, error) {
 	hashes := []common.Hash{}
 	for _, k := range storageKeys {
 		var h common.Hash
@@ -102,11 +225,30 @@ func (cl ETHClient) getProof(address common.Address, storageKeys [][]byte, block
 		}
 		hashes = append(hashes, h)
 	}
-	var msg json.RawMessage
-	if err := cl.rpcClient.Call(&msg, "eth_getProof", address, hashes, blockNumber); err != nil {
+	return hashes, nil
+}
+
+func makeStorageProof(key, value string, proofRLP []byte) (*StorageProof, error) {
+	k, err := decodeHexString(key)
+	if err != nil {
 		return nil, err
+	} else if len(k) > common.HashLength {
+		return nil, fmt.Errorf("invalid storage key length: %v", key)
 	}
-	return msg, nil
+	v, err := decodeHexString(value)
+	if err != nil {
+		return nil, err
+	} else if len(v) > common.HashLength {
+		return nil, fmt.Errorf("invalid storage value length: %v", value)
+	}
+	sp := StorageProof{
+		Key:      common.BytesToHash(k),
+		Value:    common.BytesToHash(v),
+		ProofRLP: proofRLP,
+	}
+	// an EVM storage slot with zero value doesn't exist in the storage trie
+	sp.Exists = sp.Value != (common.Hash{})
+	return &sp, nil
 }
 
 func encodeRLP(proof []string) ([]byte, error) {
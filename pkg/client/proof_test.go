@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/ibc/core/commitment"
+)
+
+func TestGetProofBatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	service := &testProofService{}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	defer server.Stop()
+	cl := NewETHClientFromRPCClient(rpc.DialInProc(server), WithMaxProofKeys(2))
+
+	var keys [][]byte
+	for seq := uint64(1); seq <= 5; seq++ {
+		keys = append(keys, []byte(commitment.PacketCommitmentSlot("transfer", "channel-0", seq)))
+	}
+	proof, err := cl.GetProofBatch(ctx, common.Address{1}, keys, BlockRef(big.NewInt(10)))
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 2, 1}, service.calls)
+	require.Len(t, proof.StorageProofs, len(keys))
+	require.Len(t, proof.StorageProofRLP, len(keys))
+	for i, key := range keys {
+		require.Equal(t, common.HexToHash(string(key)), proof.StorageProofs[i].Key)
+		require.True(t, proof.StorageProofs[i].Exists)
+	}
+
+	// a single chunk is queried without a batch
+	service.calls = nil
+	proof, err = cl.GetProofBatch(ctx, common.Address{1}, keys[:2], BlockRef(big.NewInt(10)))
+	require.NoError(t, err)
+	require.Equal(t, []int{2}, service.calls)
+	require.Len(t, proof.StorageProofs, 2)
+}
+
+// testProofService returns proofs whose values are 1 and whose merkle proofs are empty
+type testProofService struct {
+	mu    sync.Mutex
+	calls []int
+}
+
+func (s *testProofService) GetProof(ctx context.Context, address common.Address, keys []common.Hash, block string) (map[string]interface{}, error) {
+	if block != "0xa" {
+		return nil, fmt.Errorf("unexpected block: %v", block)
+	}
+	s.mu.Lock()
+	s.calls = append(s.calls, len(keys))
+	s.mu.Unlock()
+	var storageProof []map[string]interface{}
+	for _, key := range keys {
+		storageProof = append(storageProof, map[string]interface{}{
+			"key":   key.Hex(),
+			"value": "0x1",
+			"proof": []string{},
+		})
+	}
+	return map[string]interface{}{
+		"balance":      "0x0",
+		"codeHash":     common.Hash{}.Hex(),
+		"nonce":        "0x0",
+		"storageHash":  common.Hash{2}.Hex(),
+		"accountProof": []string{},
+		"storageProof": storageProof,
+	}, nil
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ReplayRevertData recovers the revert data of a failed transaction for nodes that don't return `revertReason` in receipts (e.g. ganache, geth and anvil).
+// It uses `debug_traceTransaction` if it is available, otherwise it replays the transaction with `eth_call` at the parent block.
+// NOTE: `eth_call` is executed on the state of the parent block, so the result may differ if the transaction depends on preceding transactions in the same block.
+func (cl *ETHClient) ReplayRevertData(ctx context.Context, receipt *gethtypes.Receipt) ([]byte, error) {
+	if receipt.Status == gethtypes.ReceiptStatusSuccessful {
+		return nil, errors.New("the transaction succeeded")
+	}
+	if data, err := cl.traceRevertData(ctx, receipt.TxHash); err == nil {
+		return data, nil
+	}
+	return cl.callRevertData(ctx, receipt)
+}
+
+func (cl *ETHClient) traceRevertData(ctx context.Context, txHash common.Hash) ([]byte, error) {
+	var trace struct {
+		Output hexutil.Bytes `json:"output"`
+		Error  string        `json:"error"`
+	}
+	if err := cl.rpcClient.CallContext(ctx, &trace, "debug_traceTransaction", txHash, map[string]interface{}{"tracer": "callTracer"}); err != nil {
+		return nil, err
+	}
+	if len(trace.Output) == 0 {
+		return nil, fmt.Errorf("revert data not found in the trace: error=%v", trace.Error)
+	}
+	return trace.Output, nil
+}
+
+func (cl *ETHClient) callRevertData(ctx context.Context, receipt *gethtypes.Receipt) ([]byte, error) {
+	if receipt.BlockNumber == nil || receipt.BlockNumber.Sign() == 0 {
+		return nil, fmt.Errorf("invalid block number: %v", receipt.BlockNumber)
+	}
+	var tx *struct {
+		From  common.Address  `json:"from"`
+		To    *common.Address `json:"to"`
+		Gas   hexutil.Uint64  `json:"gas"`
+		Value *hexutil.Big    `json:"value"`
+		Input hexutil.Bytes   `json:"input"`
+	}
+	if err := cl.rpcClient.CallContext(ctx, &tx, "eth_getTransactionByHash", receipt.TxHash); err != nil {
+		return nil, err
+	} else if tx == nil {
+		return nil, fmt.Errorf("transaction not found: %v", receipt.TxHash)
+	}
+	args := map[string]interface{}{
+		"from":  tx.From,
+		"to":    tx.To,
+		"gas":   tx.Gas,
+		"value": tx.Value,
+		"data":  tx.Input,
+	}
+	parent := new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1))
+	var result hexutil.Bytes
+	err := cl.rpcClient.CallContext(ctx, &result, "eth_call", args, hexutil.EncodeBig(parent))
+	if err == nil {
+		return nil, fmt.Errorf("the transaction didn't revert in the replay: block=%v", parent)
+	}
+	return revertDataFromError(err)
+}
+
+// revertDataFromError extracts the revert data from an error returned by `eth_call` or `eth_estimateGas`.
+func revertDataFromError(err error) ([]byte, error) {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil, err
+	}
+	s, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected error data: %v", dataErr.ErrorData())
+	}
+	return hexutil.Decode(s)
+}
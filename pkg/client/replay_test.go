@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayRevertData(t *testing.T) {
+	revertData := append(append([]byte{}, errorSelector...), common.Hex2Bytes(
+		"0000000000000000000000000000000000000000000000000000000000000020"+
+			"0000000000000000000000000000000000000000000000000000000000000004"+
+			"7465737400000000000000000000000000000000000000000000000000000000",
+	)...)
+
+	cases := map[string]struct {
+		trace   bool
+		opts    []Option
+		reason  string
+		replays bool
+	}{
+		"trace":    {trace: true, reason: "test", replays: true},
+		"eth_call": {trace: false, reason: "test", replays: true},
+		"disabled": {trace: true, opts: []Option{WithRevertReplay(false)}},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			server := rpc.NewServer()
+			defer server.Stop()
+			require.NoError(t, server.RegisterName("eth", &testRevertService{data: revertData}))
+			if c.trace {
+				require.NoError(t, server.RegisterName("debug", &testDebugService{data: revertData}))
+			}
+			cl := NewETHClientFromRPCClient(rpc.DialInProc(server), c.opts...)
+
+			rc, recoverable, err := cl.GetTransactionReceipt(ctx, common.Hash{1})
+			require.False(t, recoverable)
+			require.NotNil(t, rc)
+			require.Error(t, err)
+			var revertErr *RevertError
+			if !c.replays {
+				require.False(t, errors.As(err, &revertErr))
+				return
+			}
+			require.True(t, errors.As(err, &revertErr), err)
+			require.Equal(t, c.reason, revertErr.Reason.String())
+			require.Equal(t, revertData, revertErr.Data)
+		})
+	}
+}
+
+// testRevertService is an eth service that returns a failed receipt without `revertReason`
+type testRevertService struct {
+	data []byte
+}
+
+func (s *testRevertService) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*gethtypes.Receipt, error) {
+	return &gethtypes.Receipt{
+		Status:      gethtypes.ReceiptStatusFailed,
+		TxHash:      hash,
+		Logs:        []*gethtypes.Log{},
+		BlockNumber: big.NewInt(10),
+	}, nil
+}
+
+func (s *testRevertService) GetTransactionByHash(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"from":  common.Address{1},
+		"to":    common.Address{2},
+		"gas":   hexutil.Uint64(100000),
+		"value": (*hexutil.Big)(big.NewInt(0)),
+		"input": hexutil.Bytes{0x01, 0x02, 0x03, 0x04},
+	}, nil
+}
+
+func (s *testRevertService) Call(ctx context.Context, args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	if block != "0x9" {
+		return nil, errors.New("unexpected block")
+	}
+	return nil, &testRevertError{data: s.data}
+}
+
+type testRevertError struct {
+	data []byte
+}
+
+func (e *testRevertError) Error() string          { return "execution reverted" }
+func (e *testRevertError) ErrorCode() int         { return 3 }
+func (e *testRevertError) ErrorData() interface{} { return hexutil.Encode(e.data) }
+
+type testDebugService struct {
+	data []byte
+}
+
+func (s *testDebugService) TraceTransaction(ctx context.Context, hash common.Hash, config map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"output": hexutil.Bytes(s.data),
+		"error":  "execution reverted",
+	}, nil
+}
@@ -0,0 +1,189 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/contract/erc20"
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/contract/ibchandler"
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/contract/ics20bank"
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/contract/ics20transferbank"
+)
+
+var (
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// RevertReason is a reason decoded from the revert data of a transaction.
+// It is one of ErrorString, PanicReason or CustomErrorReason.
+type RevertReason interface {
+	String() string
+}
+
+// ErrorString is a reason given by `revert(string)` or `require(bool, string)`
+type ErrorString string
+
+func (r ErrorString) String() string {
+	return string(r)
+}
+
+// PanicReason is a reason given by `Panic(uint256)`
+type PanicReason struct {
+	Code *big.Int
+}
+
+// panicDescriptions are descriptions of the panic codes generated by the solidity compiler.
+// see https://docs.soliditylang.org/en/latest/control-structures.html#panic-via-assert-and-error-via-require
+var panicDescriptions = map[uint64]string{
+	0x00: "generic compiler inserted panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "incorrectly encoded storage byte array",
+	0x31: "pop on empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to zero-initialized internal function",
+}
+
+func (r PanicReason) Description() string {
+	if r.Code.IsUint64() {
+		if desc, ok := panicDescriptions[r.Code.Uint64()]; ok {
+			return desc
+		}
+	}
+	return "unknown panic code"
+}
+
+func (r PanicReason) String() string {
+	return fmt.Sprintf("panic: %v (0x%x)", r.Description(), r.Code)
+}
+
+// CustomErrorReason is a reason given by a custom error defined in an ABI
+type CustomErrorReason struct {
+	Error abi.Error
+	Args  []interface{}
+}
+
+func (r CustomErrorReason) String() string {
+	args := make([]string, len(r.Args))
+	for i, arg := range r.Args {
+		args[i] = fmt.Sprint(arg)
+	}
+	return fmt.Sprintf("%v(%v)", r.Error.Name, strings.Join(args, ", "))
+}
+
+// RevertError is an error of a reverted transaction with the decoded reason
+type RevertError struct {
+	Reason RevertReason
+	Data   []byte
+}
+
+func (e *RevertError) Error() string {
+	return fmt.Sprintf("revert: %v", e.Reason)
+}
+
+// ErrorDecoder decodes revert data into a RevertReason
+type ErrorDecoder struct {
+	errors map[[4]byte]abi.Error
+}
+
+// NewErrorDecoder returns a decoder that matches custom errors against the given ABIs
+func NewErrorDecoder(abis ...abi.ABI) *ErrorDecoder {
+	d := &ErrorDecoder{errors: make(map[[4]byte]abi.Error)}
+	for _, a := range abis {
+		for _, e := range a.Errors {
+			var sel [4]byte
+			copy(sel[:], e.ID[:4])
+			d.errors[sel] = e
+		}
+	}
+	return d
+}
+
+var defaultErrorDecoder *ErrorDecoder
+
+func init() {
+	var abis []abi.ABI
+	for _, s := range []string{
+		ibchandler.IbchandlerABI,
+		ics20bank.Ics20bankABI,
+		ics20transferbank.Ics20transferbankABI,
+		erc20.Erc20ABI,
+	} {
+		a, err := abi.JSON(strings.NewReader(s))
+		if err != nil {
+			panic(err)
+		}
+		abis = append(abis, a)
+	}
+	defaultErrorDecoder = NewErrorDecoder(abis...)
+}
+
+// DefaultErrorDecoder returns a decoder for the custom errors of IBCHandler, ICS20Bank, ICS20TransferBank and ERC20
+func DefaultErrorDecoder() *ErrorDecoder {
+	return defaultErrorDecoder
+}
+
+// Decode decodes the revert data. It returns an error if the data is malformed or the selector is unknown.
+func (d *ErrorDecoder) Decode(data []byte) (RevertReason, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("invalid length: %v", len(data))
+	}
+	selector := data[:4]
+	switch {
+	case bytes.Equal(selector, errorSelector):
+		reason, err := abi.UnpackRevert(data)
+		if err != nil {
+			return nil, err
+		}
+		return ErrorString(reason), nil
+	case bytes.Equal(selector, panicSelector):
+		// 4byte: Function selector for Panic(uint256)
+		// 32byte: Panic code
+		if l := len(data); l != 36 {
+			return nil, fmt.Errorf("invalid length of panic: %v", l)
+		}
+		return PanicReason{Code: new(big.Int).SetBytes(data[4:])}, nil
+	}
+	var sel [4]byte
+	copy(sel[:], selector)
+	e, ok := d.errors[sel]
+	if !ok {
+		return nil, fmt.Errorf("unknown error selector: 0x%x", selector)
+	}
+	args, err := e.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	return CustomErrorReason{Error: e, Args: args}, nil
+}
+
+// revertError returns a RevertError of the data decoded with the configured decoder
+func (cl *ETHClient) revertError(data []byte) error {
+	reason, err := cl.option.errorDecoder.Decode(data)
+	if err != nil {
+		return fmt.Errorf("revert: data=0x%x(parse-err=%v)", data, err)
+	}
+	return &RevertError{Reason: reason, Data: data}
+}
+
+// parseRevertReason returns the string representation of the revert data.
+// If the data is empty, it returns an empty string.
+func parseRevertReason(bz []byte) (string, error) {
+	if len(bz) == 0 {
+		return "", nil
+	}
+	reason, err := defaultErrorDecoder.Decode(bz)
+	if err != nil {
+		return "", err
+	}
+	return reason.String(), nil
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// SimulateTransaction executes the message with `eth_call` at the pending block and returns the estimated gas.
+// If the execution reverts, a RevertError with the decoded reason is returned.
+func (cl *ETHClient) SimulateTransaction(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	args := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+		"data": hexutil.Bytes(msg.Data),
+	}
+	if msg.Value != nil {
+		args["value"] = (*hexutil.Big)(msg.Value)
+	}
+	var result hexutil.Bytes
+	if err := cl.rpcClient.CallContext(ctx, &result, "eth_call", args, "pending"); err != nil {
+		data, derr := revertDataFromError(err)
+		if derr != nil || len(data) == 0 {
+			return 0, err
+		}
+		return 0, cl.revertError(data)
+	}
+	gas, err := cl.EstimateGas(ctx, msg)
+	if err != nil {
+		if data, derr := revertDataFromError(err); derr == nil && len(data) > 0 {
+			return 0, cl.revertError(data)
+		}
+		return 0, err
+	}
+	return gas, nil
+}
+
+// SimulateSignedTransaction simulates the signed transaction. See SimulateTransaction.
+func (cl *ETHClient) SimulateSignedTransaction(ctx context.Context, signer gethtypes.Signer, tx *gethtypes.Transaction) (uint64, error) {
+	from, err := gethtypes.Sender(signer, tx)
+	if err != nil {
+		return 0, err
+	}
+	return cl.SimulateTransaction(ctx, ethereum.CallMsg{
+		From:  from,
+		To:    tx.To(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	})
+}
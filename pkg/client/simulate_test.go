@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateTransaction(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	service := &testEthService{number: 1}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	defer server.Stop()
+	cl := NewETHClientFromRPCClient(rpc.DialInProc(server))
+	to := common.Address{2}
+
+	// 1. Success
+	gas, err := cl.SimulateTransaction(ctx, ethereum.CallMsg{From: common.Address{1}, To: &to, Data: []byte{0x01}})
+	require.NoError(t, err)
+	require.Equal(t, uint64(100000), gas)
+
+	// 2. Revert with a decoded reason
+	panicData := append(append([]byte{}, panicSelector...), common.LeftPadBytes([]byte{0x11}, 32)...)
+	_, err = cl.SimulateTransaction(ctx, ethereum.CallMsg{From: common.Address{1}, To: &to, Data: panicData})
+	var revertErr *RevertError
+	require.True(t, errors.As(err, &revertErr), err)
+	require.Equal(t, PanicReason{Code: big.NewInt(0x11)}.String(), revertErr.Reason.String())
+}
+
+// Call reverts with the input as the revert data if the input is longer than 4 bytes
+func (s *testEthService) Call(ctx context.Context, args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	if block != "pending" {
+		return nil, errors.New("unexpected block")
+	}
+	input, _ := args["data"].(string)
+	data, err := hexutil.Decode(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 4 {
+		return nil, &testRevertError{data: data}
+	}
+	return hexutil.Bytes{}, nil
+}
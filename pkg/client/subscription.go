@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// WatchNewHeads subscribes to new block headers.
+// If the endpoint doesn't support subscriptions(e.g. HTTP), the latest header is polled at the poll interval instead.
+// A failed poll is retried at the next interval. The polling subscription ends with the error if the polls fail consecutively
+// as many times as the limit(see WithMaxPollFailures) or the error is permanent, e.g. an authorization failure.
+func (cl *ETHClient) WatchNewHeads(ctx context.Context, ch chan<- *gethtypes.Header) (ethereum.Subscription, error) {
+	sub, err := cl.Client.SubscribeNewHead(ctx, ch)
+	if err != rpc.ErrNotificationsUnsupported {
+		return sub, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ticker := time.NewTicker(cl.option.pollInterval)
+		defer ticker.Stop()
+		var (
+			last     *big.Int
+			failures = pollFailures{max: cl.option.maxPollFailures}
+		)
+		for {
+			header, err := cl.HeaderByNumber(ctx, nil)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				} else if err := failures.add(err); err != nil {
+					return err
+				}
+			} else {
+				failures.reset()
+				if last == nil || header.Number.Cmp(last) > 0 {
+					last = header.Number
+					select {
+					case ch <- header:
+					case <-quit:
+						return nil
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-quit:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}), nil
+}
+
+// WaitForNewHead waits for a block header whose number is greater than `number` and returns it.
+func (cl *ETHClient) WaitForNewHead(ctx context.Context, number *big.Int) (*gethtypes.Header, error) {
+	heads := make(chan *gethtypes.Header, 1)
+	sub, err := cl.WatchNewHeads(ctx, heads)
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Unsubscribe()
+
+	// the head may have been already updated before subscribing
+	if header, err := cl.HeaderByNumber(ctx, nil); err != nil {
+		return nil, err
+	} else if header.Number.Cmp(number) > 0 {
+		return header, nil
+	}
+	for {
+		select {
+		case header := <-heads:
+			if header.Number.Cmp(number) > 0 {
+				return header, nil
+			}
+		case err := <-sub.Err():
+			return nil, err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// WaitForConfirmations waits until `n` blocks are built on top of the block including the receipt's transaction and returns the latest receipt.
+// If the transaction is moved to another block by a reorg, it waits for the confirmations of the new block.
+func (cl *ETHClient) WaitForConfirmations(ctx context.Context, rc *gethtypes.Receipt, n uint64) (*gethtypes.Receipt, error) {
+	for n > 0 {
+		target := new(big.Int).Add(rc.BlockNumber, new(big.Int).SetUint64(n-1))
+		if _, err := cl.WaitForNewHead(ctx, target); err != nil {
+			return nil, err
+		}
+		latest, _, err := cl.GetTransactionReceipt(ctx, rc.TxHash)
+		if err == ethereum.NotFound {
+			return nil, fmt.Errorf("the transaction was removed by a reorg: tx=%v", rc.TxHash)
+		} else if err != nil {
+			return nil, err
+		} else if latest.BlockHash == rc.BlockHash && latest.BlockNumber.Cmp(rc.BlockNumber) == 0 {
+			return latest, nil
+		}
+		rc = latest
+	}
+	return rc, nil
+}
+
+// WatchLogs subscribes to logs matching the given query.
+// If the endpoint doesn't support subscriptions, the logs are queried for each new head instead.
+// NOTE: In the polling mode, `q.FromBlock` is used as the first block to query and `q.ToBlock` is ignored.
+// A failed query is retried with the next head in the same way as the polling of WatchNewHeads.
+func (cl *ETHClient) WatchLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- gethtypes.Log) (ethereum.Subscription, error) {
+	sub, err := cl.Client.SubscribeFilterLogs(ctx, q, ch)
+	if err != rpc.ErrNotificationsUnsupported {
+		return sub, err
+	}
+	heads := make(chan *gethtypes.Header, 1)
+	headSub, err := cl.WatchNewHeads(ctx, heads)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer headSub.Unsubscribe()
+		var (
+			next     = q.FromBlock
+			failures = pollFailures{max: cl.option.maxPollFailures}
+		)
+		for {
+			select {
+			case header := <-heads:
+				query := q
+				query.FromBlock = next
+				if query.FromBlock == nil {
+					query.FromBlock = header.Number
+				}
+				query.ToBlock = header.Number
+				logs, err := cl.FilterLogs(ctx, query)
+				if err != nil {
+					if ctx.Err() != nil {
+						return ctx.Err()
+					} else if err := failures.add(err); err != nil {
+						return err
+					}
+					// `next` isn't advanced, so the range is queried again with the next head
+					continue
+				}
+				failures.reset()
+				for _, log := range logs {
+					select {
+					case ch <- log:
+					case <-quit:
+						return nil
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+				next = new(big.Int).Add(header.Number, big.NewInt(1))
+			case err := <-headSub.Err():
+				return err
+			case <-quit:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}), nil
+}
+
+// pollFailures counts the consecutive failures of a polling subscription
+type pollFailures struct {
+	count int
+	max   int
+}
+
+// add records a failure and returns the error to end the subscription with, or nil if the poll should be retried
+func (f *pollFailures) add(err error) error {
+	f.count++
+	if isPermanentError(err) || f.count >= f.max {
+		return fmt.Errorf("polling failed %v times consecutively: %w", f.count, err)
+	}
+	return nil
+}
+
+func (f *pollFailures) reset() {
+	f.count = 0
+}
+
+// isPermanentError returns true if retrying the request can't succeed, e.g. the endpoint rejects the authorization or doesn't support the method
+func isPermanentError(err error) bool {
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusMethodNotAllowed:
+			return true
+		}
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		// -32601: method not found, -32602: invalid params
+		return rpcErr.ErrorCode() == -32601 || rpcErr.ErrorCode() == -32602
+	}
+	return false
+}
@@ -0,0 +1,282 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForNewHead(t *testing.T) {
+	service := &testEthService{number: 1}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	defer server.Stop()
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	httpClient, err := NewETHClient(httpServer.URL, WithPollInterval(10*time.Millisecond), WithHeader("Authorization", "Bearer test"))
+	require.NoError(t, err)
+
+	for name, cl := range map[string]*ETHClient{
+		"inproc": NewETHClientFromRPCClient(rpc.DialInProc(server)),
+		"http":   httpClient,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			current := service.current()
+			// 1. The head is already newer
+			header, err := cl.WaitForNewHead(ctx, new(big.Int).Sub(current, big.NewInt(1)))
+			require.NoError(t, err)
+			require.Equal(t, current.Uint64(), header.Number.Uint64())
+
+			// 2. Wait for a new head
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				service.mine()
+			}()
+			header, err = cl.WaitForNewHead(ctx, current)
+			require.NoError(t, err)
+			require.Equal(t, current.Uint64()+1, header.Number.Uint64())
+
+			// 3. Timeout
+			ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			_, err = cl.WaitForNewHead(ctx, header.Number)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestWaitForConfirmations(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	service := &testEthService{number: 1, mineFrom: 1}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	defer server.Stop()
+	cl := NewETHClientFromRPCClient(rpc.DialInProc(server), WithConfirmations(3))
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	tx, err := gethtypes.SignTx(gethtypes.NewTx(&gethtypes.LegacyTx{GasPrice: big.NewInt(1), Gas: 21000}), gethtypes.HomesteadSigner{}, key)
+	require.NoError(t, err)
+	require.NoError(t, cl.SendTransaction(ctx, tx))
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-time.After(20 * time.Millisecond):
+				service.mine()
+			case <-done:
+				return
+			}
+		}
+	}()
+	rc, err := cl.WaitForReceiptAndGet(ctx, tx)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, service.current().Uint64(), rc.BlockNumber.Uint64()+2)
+}
+
+func TestWatchNewHeadsPollingRetry(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	service := &testEthService{number: 1, failHeads: 3}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	defer server.Stop()
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	cl, err := NewETHClient(httpServer.URL, WithPollInterval(10*time.Millisecond))
+	require.NoError(t, err)
+
+	// the polling continues after the failed polls
+	heads := make(chan *gethtypes.Header, 1)
+	sub, err := cl.WatchNewHeads(ctx, heads)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	select {
+	case header := <-heads:
+		require.Equal(t, uint64(1), header.Number.Uint64())
+	case err := <-sub.Err():
+		t.Fatal(err)
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	}
+	service.mu.Lock()
+	require.Zero(t, service.failHeads)
+	service.mu.Unlock()
+}
+
+func TestPollingFailures(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	service := &testEthService{number: 1, failHeads: 1000}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	defer server.Stop()
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	cl, err := NewETHClient(httpServer.URL, WithPollInterval(10*time.Millisecond), WithMaxPollFailures(3))
+	require.NoError(t, err)
+
+	waitErr := func(sub ethereum.Subscription) error {
+		defer sub.Unsubscribe()
+		select {
+		case err := <-sub.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// 1. The polling of heads ends after the consecutive failures
+	sub, err := cl.WatchNewHeads(ctx, make(chan *gethtypes.Header, 1))
+	require.NoError(t, err)
+	require.ErrorContains(t, waitErr(sub), "polling failed 3 times")
+
+	// 2. The polling of logs ends after the consecutive failures
+	service.mu.Lock()
+	service.failHeads = 0
+	service.failLogs = true
+	service.mu.Unlock()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-time.After(10 * time.Millisecond):
+				service.mine()
+			case <-done:
+				return
+			}
+		}
+	}()
+	sub, err = cl.WatchLogs(ctx, ethereum.FilterQuery{}, make(chan gethtypes.Log, 1))
+	require.NoError(t, err)
+	require.ErrorContains(t, waitErr(sub), "polling failed 3 times")
+
+	// 3. A permanent error ends the polling immediately
+	unauthorized := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer unauthorized.Close()
+	cl, err = NewETHClient(unauthorized.URL, WithPollInterval(10*time.Millisecond), WithMaxPollFailures(1000))
+	require.NoError(t, err)
+	sub, err = cl.WatchNewHeads(ctx, make(chan *gethtypes.Header, 1))
+	require.NoError(t, err)
+	require.ErrorContains(t, waitErr(sub), "polling failed 1 times")
+}
+
+type testEthService struct {
+	mu      sync.Mutex
+	chainID int64
+	number  int64
+	feed    event.Feed
+
+	nonce   uint64
+	sendErr error
+	// failHeads is the number of the next header queries to fail
+	failHeads int
+	failLogs  bool
+	mineFrom  int
+	sent      []*gethtypes.Transaction
+	included  map[common.Hash]int64
+}
+
+func (s *testEthService) current() *big.Int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return big.NewInt(s.number)
+}
+
+func (s *testEthService) mine() {
+	s.mu.Lock()
+	s.number++
+	header := s.header()
+	s.mu.Unlock()
+	s.feed.Send(header)
+}
+
+func (s *testEthService) header() *gethtypes.Header {
+	return s.headerAt(s.number)
+}
+
+func (s *testEthService) headerAt(number int64) *gethtypes.Header {
+	return &gethtypes.Header{Number: big.NewInt(number), Difficulty: big.NewInt(0)}
+}
+
+func (s *testEthService) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool) (*gethtypes.Header, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failHeads > 0 {
+		s.failHeads--
+		return nil, errors.New("temporarily unavailable")
+	}
+	if n := number.Int64(); n >= 0 {
+		if n > s.number {
+			return nil, nil
+		}
+		return s.headerAt(n), nil
+	}
+	return s.header(), nil
+}
+
+func (s *testEthService) GetLogs(ctx context.Context, crit map[string]interface{}) ([]gethtypes.Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failLogs {
+		return nil, errors.New("temporarily unavailable")
+	}
+	return []gethtypes.Log{}, nil
+}
+
+func (s *testEthService) BlockNumber(ctx context.Context) (hexutil.Uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return hexutil.Uint64(s.number), nil
+}
+
+func (s *testEthService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	heads := make(chan *gethtypes.Header)
+	feedSub := s.feed.Subscribe(heads)
+	go func() {
+		defer feedSub.Unsubscribe()
+		for {
+			select {
+			case header := <-heads:
+				if err := notifier.Notify(sub.ID, header); err != nil {
+					return
+				}
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
@@ -0,0 +1,414 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// FeeMode determines the fee fields of transactions
+type FeeMode int
+
+const (
+	// FeeModeAuto uses dynamic fee transactions if the latest header has a base fee, otherwise legacy transactions
+	FeeModeAuto FeeMode = iota
+	// FeeModeDynamic always uses dynamic fee(EIP-1559) transactions
+	FeeModeDynamic
+	// FeeModeLegacy always uses legacy transactions
+	FeeModeLegacy
+)
+
+// TxManager is a bind.ContractBackend that manages transactions sent through contract bindings.
+// It provides the followings:
+// - nonce tracking per sender without querying the node for each transaction
+// - gas estimation with a safety margin
+// - fee selection based on FeeMode
+// - replacement of transactions that are not mined within a deadline
+type TxManager struct {
+	*ETHClient
+
+	signer gethtypes.Signer
+	option txManagerOption
+
+	mu      sync.Mutex
+	nonces  map[common.Address]uint64
+	signers map[common.Address]bind.SignerFn
+	// sent keeps the hashes of the transactions sent for each (sender, nonce) including replacements
+	sent map[pendingTxKey][]*gethtypes.Transaction
+}
+
+type pendingTxKey struct {
+	from  common.Address
+	nonce uint64
+}
+
+var _ bind.ContractBackend = (*TxManager)(nil)
+
+type TxManagerOption func(*txManagerOption)
+
+type txManagerOption struct {
+	// gasMargin is a percentage added to the estimated gas
+	gasMargin uint64
+	feeMode   FeeMode
+	// defaultGasTipCap is used if the node doesn't support `eth_maxPriorityFeePerGas`
+	defaultGasTipCap *big.Int
+	// replaceAfter is the deadline to wait for a transaction to be mined before replacing it
+	replaceAfter time.Duration
+	// bumpPercent is a percentage added to the fees of a replacement transaction
+	bumpPercent     uint64
+	maxReplacements int
+}
+
+func DefaultTxManagerOption() *txManagerOption {
+	return &txManagerOption{
+		gasMargin:        20,
+		feeMode:          FeeModeAuto,
+		defaultGasTipCap: big.NewInt(1_000_000_000), // 1 gwei
+		replaceAfter:     30 * time.Second,
+		bumpPercent:      20,
+		maxReplacements:  3,
+	}
+}
+
+func WithGasMargin(percent uint64) TxManagerOption {
+	return func(opt *txManagerOption) {
+		opt.gasMargin = percent
+	}
+}
+
+func WithFeeMode(mode FeeMode) TxManagerOption {
+	return func(opt *txManagerOption) {
+		opt.feeMode = mode
+	}
+}
+
+func WithDefaultGasTipCap(tip *big.Int) TxManagerOption {
+	return func(opt *txManagerOption) {
+		opt.defaultGasTipCap = tip
+	}
+}
+
+// WithReplacement configures the replacement of stuck transactions.
+// NOTE: most nodes reject a replacement transaction if its fees are not bumped by at least 10%.
+func WithReplacement(after time.Duration, bumpPercent uint64, maxReplacements int) TxManagerOption {
+	return func(opt *txManagerOption) {
+		opt.replaceAfter = after
+		opt.bumpPercent = bumpPercent
+		opt.maxReplacements = maxReplacements
+	}
+}
+
+func NewTxManager(client *ETHClient, chainID *big.Int, opts ...TxManagerOption) *TxManager {
+	opt := DefaultTxManagerOption()
+	for _, o := range opts {
+		o(opt)
+	}
+	return &TxManager{
+		ETHClient: client,
+		signer:    gethtypes.LatestSignerForChainID(chainID),
+		option:    *opt,
+		nonces:    make(map[common.Address]uint64),
+		signers:   make(map[common.Address]bind.SignerFn),
+		sent:      make(map[pendingTxKey][]*gethtypes.Transaction),
+	}
+}
+
+// TransactOpts returns TransactOpts for `from`. The signer is also used to sign replacement transactions.
+// The nonce, fees and gas limit are filled by the manager when a contract binding created with the manager sends a transaction.
+// If signing fails, the reserved nonce is released.
+func (m *TxManager) TransactOpts(ctx context.Context, from common.Address, signer bind.SignerFn) *bind.TransactOpts {
+	m.mu.Lock()
+	m.signers[from] = signer
+	m.mu.Unlock()
+	return &bind.TransactOpts{
+		From: from,
+		Signer: func(address common.Address, tx *gethtypes.Transaction) (*gethtypes.Transaction, error) {
+			signed, err := signer(address, tx)
+			if err != nil {
+				m.releaseNonce(address, tx.Nonce())
+				return nil, err
+			}
+			return signed, nil
+		},
+		Context: ctx,
+	}
+}
+
+// PendingNonceAt returns the next nonce of `account` and reserves it.
+// The node is queried only if the nonce is not cached, i.e. for the first transaction of `account` or after ResetNonce.
+func (m *TxManager) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	m.mu.Lock()
+	if next, ok := m.nonces[account]; ok {
+		m.nonces[account] = next + 1
+		m.mu.Unlock()
+		return next, nil
+	}
+	m.mu.Unlock()
+
+	nonce, err := m.ETHClient.PendingNonceAt(ctx, account)
+	if err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// another caller may have seeded the cache while querying the node
+	if cached, ok := m.nonces[account]; ok && cached > nonce {
+		nonce = cached
+	}
+	m.nonces[account] = nonce + 1
+	return nonce, nil
+}
+
+// releaseNonce releases the nonce reserved for a transaction that was not sent.
+// If a later nonce has been reserved in the meantime, the cache is discarded to avoid a gap, so the next nonce is queried from the node.
+func (m *TxManager) releaseNonce(account common.Address, nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if next, ok := m.nonces[account]; ok && next == nonce+1 {
+		m.nonces[account] = nonce
+	} else {
+		delete(m.nonces, account)
+	}
+}
+
+// ResetNonce discards the cached nonce of `account`. The next nonce is queried from the node.
+func (m *TxManager) ResetNonce(account common.Address) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.nonces, account)
+}
+
+// EstimateGas returns the estimated gas with the safety margin.
+func (m *TxManager) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	gas, err := m.ETHClient.EstimateGas(ctx, msg)
+	if err != nil {
+		return 0, err
+	}
+	return gas + gas*m.option.gasMargin/100, nil
+}
+
+// HeaderByNumber returns the header. If the fee mode is legacy, the base fee of the header is dropped to make bind use legacy transactions.
+func (m *TxManager) HeaderByNumber(ctx context.Context, number *big.Int) (*gethtypes.Header, error) {
+	header, err := m.ETHClient.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	switch m.option.feeMode {
+	case FeeModeLegacy:
+		header = gethtypes.CopyHeader(header)
+		header.BaseFee = nil
+	case FeeModeDynamic:
+		if header.BaseFee == nil {
+			return nil, fmt.Errorf("dynamic fee is not supported: block=%v", header.Number)
+		}
+	}
+	return header, nil
+}
+
+// SuggestGasTipCap returns the tip suggested by the node or the default tip if the node doesn't support it.
+func (m *TxManager) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	tip, err := m.ETHClient.SuggestGasTipCap(ctx)
+	if err != nil {
+		return new(big.Int).Set(m.option.defaultGasTipCap), nil
+	}
+	return tip, nil
+}
+
+// SendTransaction sends the transaction and tracks it for replacement.
+// If the node rejects the transaction without using the nonce, the reserved nonce is released.
+// Otherwise, e.g. the transaction is already known, the nonce is too low or the request timed out, the cached nonce is discarded
+// because the nonce may have been used, so the next nonce is queried from the node.
+func (m *TxManager) SendTransaction(ctx context.Context, tx *gethtypes.Transaction) error {
+	from, err := gethtypes.Sender(m.signer, tx)
+	if err != nil {
+		return err
+	}
+	if err := m.ETHClient.SendTransaction(ctx, tx); err != nil {
+		if isRejectedTxError(err) {
+			m.releaseNonce(from, tx.Nonce())
+		} else {
+			m.ResetNonce(from)
+		}
+		return err
+	}
+	m.track(from, tx)
+	return nil
+}
+
+// nonceUsedTxErrors are the errors of geth and besu meaning that the nonce is used by a transaction in the pool or the chain.
+var nonceUsedTxErrors = []string{
+	"already known",
+	"known transaction",
+	"nonce too low",
+	"replacement transaction underpriced",
+}
+
+// rejectedTxErrors are the errors of geth and besu meaning that the transaction is rejected without being added to the pool.
+var rejectedTxErrors = []string{
+	"insufficient funds",
+	"upfront cost exceeds account balance",
+	"intrinsic gas too low",
+	"intrinsic gas exceeds gas limit",
+	"exceeds block gas limit",
+	"gas limit reached",
+	"transaction underpriced",
+	"gas price below configured minimum gas price",
+	"fee cap less than block base fee",
+	"tip above fee cap",
+	"oversized data",
+	"invalid sender",
+	"txpool is full",
+}
+
+// isRejectedTxError returns true if the node definitely rejected the transaction without using its nonce.
+func isRejectedTxError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, used := range nonceUsedTxErrors {
+		if strings.Contains(msg, used) {
+			return false
+		}
+	}
+	for _, rejected := range rejectedTxErrors {
+		if strings.Contains(msg, rejected) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *TxManager) track(from common.Address, tx *gethtypes.Transaction) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := pendingTxKey{from: from, nonce: tx.Nonce()}
+	m.sent[key] = append(m.sent[key], tx)
+}
+
+// WaitMined waits for the transaction or one of its replacements to be mined and returns the receipt.
+// If no transaction is mined within the deadline, the transaction is replaced with bumped fees.
+func (m *TxManager) WaitMined(ctx context.Context, tx *gethtypes.Transaction) (*gethtypes.Receipt, error) {
+	from, err := gethtypes.Sender(m.signer, tx)
+	if err != nil {
+		return nil, err
+	}
+	key := pendingTxKey{from: from, nonce: tx.Nonce()}
+	defer func() {
+		m.mu.Lock()
+		delete(m.sent, key)
+		m.mu.Unlock()
+	}()
+
+	heads := make(chan *gethtypes.Header, 1)
+	sub, err := m.WatchNewHeads(ctx, heads)
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Unsubscribe()
+
+	timer := time.NewTimer(m.option.replaceAfter)
+	defer timer.Stop()
+	var (
+		latest       = tx
+		replacements int
+		replaceErr   error
+	)
+	for {
+		for _, sent := range m.sentTxs(key, tx) {
+			rc, recoverable, err := m.GetTransactionReceipt(ctx, sent.Hash())
+			if err == nil {
+				return m.WaitForConfirmations(ctx, rc, m.ETHClient.option.confirmations)
+			} else if !recoverable {
+				return rc, err
+			}
+		}
+		select {
+		case <-heads:
+		case <-timer.C:
+			if replacements >= m.option.maxReplacements {
+				return nil, fmt.Errorf("transaction not mined after %v replacements: from=%v nonce=%v last-err=%v", replacements, from, tx.Nonce(), replaceErr)
+			}
+			// NOTE: the replacement fails if one of the sent transactions has been mined in the meantime, so the receipts are checked again
+			if replacement, err := m.replace(ctx, from, latest); err != nil {
+				replaceErr = err
+			} else {
+				latest = replacement
+			}
+			replacements++
+			timer.Reset(m.option.replaceAfter)
+		case err := <-sub.Err():
+			return nil, err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (m *TxManager) sentTxs(key pendingTxKey, tx *gethtypes.Transaction) []*gethtypes.Transaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if txs := m.sent[key]; len(txs) > 0 {
+		return append([]*gethtypes.Transaction{}, txs...)
+	}
+	return []*gethtypes.Transaction{tx}
+}
+
+// replace re-signs the transaction with bumped fees and sends it with the same nonce.
+func (m *TxManager) replace(ctx context.Context, from common.Address, tx *gethtypes.Transaction) (*gethtypes.Transaction, error) {
+	m.mu.Lock()
+	signer, ok := m.signers[from]
+	m.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("signer not found: %v", from)
+	}
+	var raw gethtypes.TxData
+	switch tx.Type() {
+	case gethtypes.LegacyTxType:
+		raw = &gethtypes.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: bumpFee(tx.GasPrice(), m.option.bumpPercent),
+			Gas:      tx.Gas(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		}
+	case gethtypes.DynamicFeeTxType:
+		raw = &gethtypes.DynamicFeeTx{
+			ChainID:   tx.ChainId(),
+			Nonce:     tx.Nonce(),
+			GasTipCap: bumpFee(tx.GasTipCap(), m.option.bumpPercent),
+			GasFeeCap: bumpFee(tx.GasFeeCap(), m.option.bumpPercent),
+			Gas:       tx.Gas(),
+			To:        tx.To(),
+			Value:     tx.Value(),
+			Data:      tx.Data(),
+		}
+	default:
+		return nil, fmt.Errorf("unsupported transaction type: %v", tx.Type())
+	}
+	signed, err := signer(from, gethtypes.NewTx(raw))
+	if err != nil {
+		return nil, err
+	}
+	if err := m.ETHClient.SendTransaction(ctx, signed); err != nil {
+		return nil, err
+	}
+	m.track(from, signed)
+	return signed, nil
+}
+
+// bumpFee returns `fee * (100 + percent) / 100`. The result is greater than `fee` at least by 1.
+func bumpFee(fee *big.Int, percent uint64) *big.Int {
+	bumped := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+percent))
+	bumped.Div(bumped, big.NewInt(100))
+	if bumped.Cmp(fee) <= 0 {
+		bumped.Add(fee, big.NewInt(1))
+	}
+	return bumped
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxManager(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	service := &testEthService{number: 1, nonce: 5, mineFrom: 2}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	defer server.Stop()
+
+	chainID := big.NewInt(1337)
+	m := NewTxManager(
+		NewETHClientFromRPCClient(rpc.DialInProc(server)),
+		chainID,
+		WithReplacement(50*time.Millisecond, 20, 2),
+	)
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	signer := gethtypes.LatestSignerForChainID(chainID)
+	opts := m.TransactOpts(ctx, from, func(address common.Address, tx *gethtypes.Transaction) (*gethtypes.Transaction, error) {
+		return gethtypes.SignTx(tx, signer, key)
+	})
+	newTx := func(nonce uint64) *gethtypes.Transaction {
+		tx, err := opts.Signer(from, gethtypes.NewTx(&gethtypes.LegacyTx{Nonce: nonce, GasPrice: big.NewInt(100), Gas: 21000}))
+		require.NoError(t, err)
+		return tx
+	}
+
+	// 1. Nonces are reserved locally
+	nonce, err := m.PendingNonceAt(ctx, from)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), nonce)
+	nonce, err = m.PendingNonceAt(ctx, from)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), nonce)
+
+	// 2. The nonce is released if the node rejects the transaction
+	service.mu.Lock()
+	service.sendErr = errors.New("insufficient funds for gas * price + value")
+	service.mu.Unlock()
+	require.Error(t, m.SendTransaction(ctx, newTx(6)))
+	service.mu.Lock()
+	service.sendErr = nil
+	service.mu.Unlock()
+	nonce, err = m.PendingNonceAt(ctx, from)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), nonce)
+
+	// 3. The cached nonce is used without querying the node until it's reset
+	service.mu.Lock()
+	service.nonce = 3
+	service.mu.Unlock()
+	nonce, err = m.PendingNonceAt(ctx, from)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), nonce)
+	m.ResetNonce(from)
+	nonce, err = m.PendingNonceAt(ctx, from)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), nonce)
+	service.mu.Lock()
+	service.nonce = 5
+	service.mu.Unlock()
+	m.ResetNonce(from)
+
+	// 4. The cached nonce is discarded if the nonce may have been used
+	for _, sendErr := range []string{"already known", "nonce too low", "replacement transaction underpriced", "i/o timeout"} {
+		nonce, err = m.PendingNonceAt(ctx, from)
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), nonce)
+		service.mu.Lock()
+		service.nonce = 7
+		service.sendErr = errors.New(sendErr)
+		service.mu.Unlock()
+		require.Error(t, m.SendTransaction(ctx, newTx(nonce)))
+		nonce, err = m.PendingNonceAt(ctx, from)
+		require.NoError(t, err)
+		require.Equal(t, uint64(7), nonce, sendErr)
+		service.mu.Lock()
+		service.nonce = 5
+		service.sendErr = nil
+		service.mu.Unlock()
+		m.ResetNonce(from)
+	}
+
+	// 5. The nonce is released if signing fails
+	failOpts := m.TransactOpts(ctx, from, func(address common.Address, tx *gethtypes.Transaction) (*gethtypes.Transaction, error) {
+		return nil, errors.New("failed to sign")
+	})
+	nonce, err = m.PendingNonceAt(ctx, from)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), nonce)
+	_, err = failOpts.Signer(from, gethtypes.NewTx(&gethtypes.LegacyTx{Nonce: nonce}))
+	require.Error(t, err)
+	nonce, err = m.PendingNonceAt(ctx, from)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), nonce)
+	nonce, err = m.PendingNonceAt(ctx, from)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), nonce)
+	// the replacements are signed with the latest signer
+	m.TransactOpts(ctx, from, opts.Signer)
+
+	// 6. Gas estimation with the margin
+	gas, err := m.EstimateGas(ctx, ethereum.CallMsg{From: from})
+	require.NoError(t, err)
+	require.Equal(t, uint64(120000), gas)
+
+	// 7. A stuck transaction is replaced with bumped fees
+	tx := newTx(6)
+	require.NoError(t, m.SendTransaction(ctx, tx))
+	rc, err := m.WaitMined(ctx, tx)
+	require.NoError(t, err)
+	require.Len(t, service.sent, 2)
+	replacement := service.sent[1]
+	require.Equal(t, replacement.Hash(), rc.TxHash)
+	require.Equal(t, tx.Nonce(), replacement.Nonce())
+	require.Equal(t, big.NewInt(120), replacement.GasPrice())
+
+	// 8. Fee bumping always increases the fee
+	require.Equal(t, big.NewInt(1), bumpFee(big.NewInt(0), 20))
+	require.Equal(t, big.NewInt(120), bumpFee(big.NewInt(100), 20))
+}
+
+func (s *testEthService) ChainId(ctx context.Context) (*hexutil.Big, error) {
+	if s.chainID != 0 {
+		return (*hexutil.Big)(big.NewInt(s.chainID)), nil
+	}
+	return (*hexutil.Big)(big.NewInt(1337)), nil
+}
+
+func (s *testEthService) GetTransactionCount(ctx context.Context, address common.Address, block string) (hexutil.Uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return hexutil.Uint64(s.nonce), nil
+}
+
+func (s *testEthService) EstimateGas(ctx context.Context, args map[string]interface{}) (hexutil.Uint64, error) {
+	return 100000, nil
+}
+
+func (s *testEthService) SendRawTransaction(ctx context.Context, input hexutil.Bytes) (common.Hash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sendErr != nil {
+		return common.Hash{}, s.sendErr
+	}
+	var tx gethtypes.Transaction
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	s.sent = append(s.sent, &tx)
+	return tx.Hash(), nil
+}
+
+// GetTransactionReceipt returns a receipt only for the transactions sent after `mineFrom` transactions.
+// The transaction is included in the block when the receipt is queried first.
+func (s *testEthService) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*gethtypes.Receipt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, tx := range s.sent {
+		if tx.Hash() == hash && i+1 >= s.mineFrom {
+			if s.included == nil {
+				s.included = make(map[common.Hash]int64)
+			}
+			if _, ok := s.included[hash]; !ok {
+				s.included[hash] = s.number
+			}
+			return &gethtypes.Receipt{
+				Status:      gethtypes.ReceiptStatusSuccessful,
+				TxHash:      hash,
+				Logs:        []*gethtypes.Log{},
+				BlockNumber: big.NewInt(s.included[hash]),
+			}, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// VerifyStateProof verifies the account proof of `address` against `stateRoot`
+// and checks that the proven account matches the fields of the given proof.
+// It returns the storage root of the account that can be used to verify the storage proofs.
+func VerifyStateProof(stateRoot common.Hash, address common.Address, proof *StateProof) (common.Hash, error) {
+	account, err := VerifyAccountProof(stateRoot, address, proof.AccountProofRLP)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if account.Root != proof.StorageHash {
+		return common.Hash{}, fmt.Errorf("storage root mismatch: expected=%x actual=%x", proof.StorageHash, account.Root)
+	} else if !bytes.Equal(account.CodeHash, proof.CodeHash[:]) {
+		return common.Hash{}, fmt.Errorf("code hash mismatch: expected=%x actual=%x", proof.CodeHash, account.CodeHash)
+	} else if account.Nonce != proof.Nonce {
+		return common.Hash{}, fmt.Errorf("nonce mismatch: expected=%v actual=%v", proof.Nonce, account.Nonce)
+	} else if account.Balance.Cmp(&proof.Balance) != 0 {
+		return common.Hash{}, fmt.Errorf("balance mismatch: expected=%v actual=%v", &proof.Balance, account.Balance)
+	}
+	return account.Root, nil
+}
+
+// VerifyAccountProof verifies `accountProofRLP` against `stateRoot` and returns the proven account.
+// The format of `accountProofRLP` must be same as the one generated by `ETHClient.GetProof`.
+func VerifyAccountProof(stateRoot common.Hash, address common.Address, accountProofRLP []byte) (*gethtypes.StateAccount, error) {
+	bz, err := verifyProof(stateRoot, crypto.Keccak256(address.Bytes()), accountProofRLP)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account proof: %v", err)
+	} else if len(bz) == 0 {
+		return nil, fmt.Errorf("account not found: %v", address)
+	}
+	var account gethtypes.StateAccount
+	if err := rlp.DecodeBytes(bz, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// VerifyMembership verifies that the storage slot `slot` has `value` under `storageRoot`.
+// `slot` is a hex string calculated by the commitment package.
+func VerifyMembership(storageRoot common.Hash, storageProofRLP []byte, slot string, value [32]byte) error {
+	proven, err := VerifyStorageProof(storageRoot, storageProofRLP, slot)
+	if err != nil {
+		return err
+	} else if proven == (common.Hash{}) {
+		return fmt.Errorf("slot not found: %v", slot)
+	} else if proven != value {
+		return fmt.Errorf("value mismatch: slot=%v expected=%x actual=%x", slot, value, proven)
+	}
+	return nil
+}
+
+// VerifyNonMembership verifies that the storage slot `slot` is empty under `storageRoot`.
+// `slot` is a hex string calculated by the commitment package.
+func VerifyNonMembership(storageRoot common.Hash, storageProofRLP []byte, slot string) error {
+	proven, err := VerifyStorageProof(storageRoot, storageProofRLP, slot)
+	if err != nil {
+		return err
+	} else if proven != (common.Hash{}) {
+		return fmt.Errorf("slot exists: slot=%v value=%x", slot, proven)
+	}
+	return nil
+}
+
+// Verify verifies the storage proof against `storageRoot`.
+// If the slot exists, it checks the membership of the value, otherwise it checks the non-membership.
+func (sp StorageProof) Verify(storageRoot common.Hash) error {
+	slot := sp.Key.Hex()
+	if sp.Exists {
+		return VerifyMembership(storageRoot, sp.ProofRLP, slot, sp.Value)
+	}
+	return VerifyNonMembership(storageRoot, sp.ProofRLP, slot)
+}
+
+// VerifyStorageProof verifies `storageProofRLP` against `storageRoot` and returns the value of the storage slot.
+// If the slot doesn't exist, it returns the zero hash.
+func VerifyStorageProof(storageRoot common.Hash, storageProofRLP []byte, slot string) (common.Hash, error) {
+	var key common.Hash
+	if err := key.UnmarshalText([]byte(slot)); err != nil {
+		return common.Hash{}, err
+	}
+	bz, err := verifyProof(storageRoot, crypto.Keccak256(key.Bytes()), storageProofRLP)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid storage proof: slot=%v err=%v", slot, err)
+	} else if len(bz) == 0 {
+		return common.Hash{}, nil
+	}
+	var value []byte
+	if err := rlp.DecodeBytes(bz, &value); err != nil {
+		return common.Hash{}, err
+	} else if len(value) > common.HashLength {
+		return common.Hash{}, fmt.Errorf("invalid storage value length: %v", len(value))
+	}
+	return common.BytesToHash(value), nil
+}
+
+// verifyProof decodes a proof encoded by `encodeRLP` and verifies it with the trie package.
+func verifyProof(root common.Hash, key []byte, proofRLP []byte) ([]byte, error) {
+	var nodes []rlp.RawValue
+	if err := rlp.DecodeBytes(proofRLP, &nodes); err != nil {
+		return nil, err
+	}
+	db := memorydb.New()
+	for _, node := range nodes {
+		if err := db.Put(crypto.Keccak256(node), node); err != nil {
+			return nil, err
+		}
+	}
+	return trie.VerifyProof(root, key, db)
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/ibc/core/commitment"
+)
+
+func TestStateProofVerifier(t *testing.T) {
+	address := common.HexToAddress("0xaa43d337145E8930d01cb4E60Abf6595C692921E")
+	existingSlot := commitment.ClientStateCommitmentSlot("ibft2-0")
+	absentSlot := commitment.PacketCommitmentSlot("transfer", "channel-0", 1)
+	value := crypto.Keccak256Hash([]byte("client-state"))
+
+	storage := trie.NewEmpty(trie.NewDatabase(rawdb.NewMemoryDatabase()))
+	for slot, v := range map[string][]byte{
+		existingSlot: common.TrimLeftZeroes(value.Bytes()),
+		commitment.ConnectionStateCommitmentSlot("connection-0"): {0x01},
+	} {
+		bz, err := rlp.EncodeToBytes(v)
+		require.NoError(t, err)
+		require.NoError(t, storage.Update(crypto.Keccak256(common.HexToHash(slot).Bytes()), bz))
+	}
+	account := gethtypes.StateAccount{
+		Nonce:    1,
+		Balance:  big.NewInt(100),
+		Root:     storage.Hash(),
+		CodeHash: crypto.Keccak256([]byte("code")),
+	}
+	accountBytes, err := rlp.EncodeToBytes(&account)
+	require.NoError(t, err)
+	state := trie.NewEmpty(trie.NewDatabase(rawdb.NewMemoryDatabase()))
+	require.NoError(t, state.Update(crypto.Keccak256(address.Bytes()), accountBytes))
+	// add another account to make the account proof non-trivial
+	require.NoError(t, state.Update(crypto.Keccak256(common.Address{1}.Bytes()), accountBytes))
+
+	proof := &StateProof{
+		Balance:     *account.Balance,
+		Nonce:       account.Nonce,
+		StorageHash: account.Root,
+	}
+	copy(proof.CodeHash[:], account.CodeHash)
+	proof.AccountProofRLP = makeProofRLP(t, state, crypto.Keccak256(address.Bytes()))
+	existingProof := makeProofRLP(t, storage, crypto.Keccak256(common.HexToHash(existingSlot).Bytes()))
+	absentProof := makeProofRLP(t, storage, crypto.Keccak256(common.HexToHash(absentSlot).Bytes()))
+
+	// 1. Valid proofs
+	storageRoot, err := VerifyStateProof(state.Hash(), address, proof)
+	require.NoError(t, err)
+	require.Equal(t, account.Root, storageRoot)
+	require.NoError(t, VerifyMembership(storageRoot, existingProof, existingSlot, value))
+	require.NoError(t, VerifyNonMembership(storageRoot, absentProof, absentSlot))
+
+	// 2. Wrong value or wrong kind of proof
+	require.Error(t, VerifyMembership(storageRoot, existingProof, existingSlot, crypto.Keccak256Hash([]byte("other"))))
+	require.Error(t, VerifyMembership(storageRoot, absentProof, absentSlot, value))
+	require.Error(t, VerifyNonMembership(storageRoot, existingProof, existingSlot))
+
+	// 3. Wrong roots
+	_, err = VerifyStateProof(storage.Hash(), address, proof)
+	require.Error(t, err)
+	require.Error(t, VerifyMembership(state.Hash(), existingProof, existingSlot, value))
+
+	// 4. Account fields mismatch
+	invalid := *proof
+	invalid.Nonce++
+	_, err = VerifyStateProof(state.Hash(), address, &invalid)
+	require.Error(t, err)
+
+	// 5. Storage proofs built from `eth_getProof` results
+	sp, err := makeStorageProof(existingSlot, value.Hex(), existingProof)
+	require.NoError(t, err)
+	require.True(t, sp.Exists)
+	require.NoError(t, sp.Verify(storageRoot))
+	sp, err = makeStorageProof(absentSlot, "0x0", absentProof)
+	require.NoError(t, err)
+	require.False(t, sp.Exists)
+	require.NoError(t, sp.Verify(storageRoot))
+	sp, err = makeStorageProof(absentSlot, "0x1", absentProof)
+	require.NoError(t, err)
+	require.Error(t, sp.Verify(storageRoot))
+
+	// 6. Malformed proof
+	_, err = VerifyStateProof(state.Hash(), address, &StateProof{AccountProofRLP: []byte{0x01}})
+	require.Error(t, err)
+}
+
+// makeProofRLP generates a proof for `key` in the same format as `ETHClient.GetProof`
+func makeProofRLP(t *testing.T, tr *trie.Trie, key []byte) []byte {
+	var nodes proofList
+	require.NoError(t, tr.Prove(key, 0, &nodes))
+	var hexNodes []string
+	for _, n := range nodes {
+		hexNodes = append(hexNodes, hexutil.Encode(n))
+	}
+	bz, err := encodeRLP(hexNodes)
+	require.NoError(t, err)
+	return bz
+}
+
+// proofList keeps the order of the nodes written by `trie.Prove`
+type proofList [][]byte
+
+func (l *proofList) Put(key []byte, value []byte) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func (l *proofList) Delete(key []byte) error {
+	return fmt.Errorf("not supported")
+}
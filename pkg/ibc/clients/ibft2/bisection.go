@@ -0,0 +1,87 @@
+package ibft2
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/ibc/core/client"
+)
+
+// HeaderSource provides the headers of the counterparty chain
+type HeaderSource interface {
+	// GetHeader returns the header at the height with the seals. TrustedHeight and AccountStateProof of the header are ignored.
+	GetHeader(ctx context.Context, height uint64) (*Header, error)
+	// GetAccountStateProof returns the account state proof of the IBC contract at the height
+	GetAccountStateProof(ctx context.Context, height uint64) ([]byte, error)
+}
+
+// PlanUpdate returns a sequence of headers to update the client from the trusted consensus state to the target height by bisection.
+// At each step, the furthest height whose header satisfies the trust threshold of the current consensus state is searched by binary search,
+// so the headers can be submitted in order. The TrustedHeight of each header is set to the height of the previous step.
+// NOTE: the sequence is greedy. It's the shortest one if a header verifiable from a consensus state implies that the headers between them are also verifiable,
+// which usually holds because the validator set changes gradually, but it's not guaranteed.
+// The account state proofs are fetched only for the headers in the sequence.
+// It returns an empty sequence if the target height is not greater than the trusted height,
+// and an error if the validator set changes so much between adjacent heights that no sequence exists.
+func PlanUpdate(ctx context.Context, source HeaderSource, trustedHeight client.Height, trusted *ConsensusState, target uint64) ([]*Header, error) {
+	if trustedHeight.RevisionNumber != 0 {
+		return nil, fmt.Errorf("revision number must be zero: %v", trustedHeight)
+	} else if target <= trustedHeight.RevisionHeight {
+		return nil, nil
+	}
+	var (
+		plan      []*Header
+		current   = trustedHeight
+		state     = trusted
+		headers   = make(map[uint64]*Header)
+		getHeader = func(height uint64) (*Header, error) {
+			if h, ok := headers[height]; ok {
+				return h, nil
+			}
+			h, err := source.GetHeader(ctx, height)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the header: height=%v err=%v", height, err)
+			}
+			headers[height] = h
+			return h, nil
+		}
+	)
+	for current.RevisionHeight < target {
+		// lo is the furthest height verified from the current state, and hi is the nearest height that failed
+		var (
+			lo, hi   = current.RevisionHeight, target + 1
+			next     *Header
+			result   *VerifyResult
+			firstErr error
+		)
+		for pivot := target; lo+1 < hi; pivot = lo + (hi-lo)/2 {
+			h, err := getHeader(pivot)
+			if err != nil {
+				return nil, err
+			}
+			header := *h
+			header.TrustedHeight = current
+			res, err := VerifyHeader(state, &header)
+			if err != nil {
+				if pivot == current.RevisionHeight+1 {
+					firstErr = err
+				}
+				hi = pivot
+				continue
+			}
+			lo, next, result = pivot, &header, res
+		}
+		if next == nil {
+			return nil, fmt.Errorf("failed to verify the adjacent header: trusted=%v height=%v err=%v", current, current.RevisionHeight+1, firstErr)
+		}
+		proof, err := source.GetAccountStateProof(ctx, lo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the account state proof: height=%v err=%v", lo, err)
+		}
+		next.AccountStateProof = proof
+		plan = append(plan, next)
+		current = result.Header.Height
+		state = &ConsensusState{Timestamp: result.Header.Time, Validators: result.Validators}
+	}
+	return plan, nil
+}
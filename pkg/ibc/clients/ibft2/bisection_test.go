@@ -0,0 +1,159 @@
+package ibft2
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"testing"
+
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/chains"
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/ibc/core/client"
+)
+
+func TestPlanUpdate(t *testing.T) {
+	a, _ := generateValidators(t, 4)
+	b, _ := generateValidators(t, 2)
+	c, _ := generateValidators(t, 2)
+	setA := a
+	setB := []*ecdsa.PrivateKey{a[0], a[1], b[0], b[1]}
+	setC := []*ecdsa.PrivateKey{b[0], b[1], c[0], c[1]}
+
+	source := &testHeaderSource{headers: make(map[uint64]*Header)}
+	for height := uint64(1); height <= 8; height++ {
+		keys := setA
+		if height >= 7 {
+			keys = setC
+		} else if height >= 4 {
+			keys = setB
+		}
+		source.add(t, height, keys)
+	}
+	trusted := &ConsensusState{Timestamp: 1, Validators: addressBytes(setA)}
+	trustedHeight := client.Height{RevisionHeight: 1}
+
+	// 1. The validator set changes too much to update directly
+	_, err := VerifyHeader(trusted, source.withTrustedHeight(8, trustedHeight))
+	require.Error(t, err)
+
+	// 2. Update through the header signed by the intermediate validator set
+	plan, err := PlanUpdate(context.Background(), source, trustedHeight, trusted, 8)
+	require.NoError(t, err)
+	var heights, trustedHeights []uint64
+	for _, h := range plan {
+		parsed, err := ParseBesuHeader(h.BesuHeaderRlp)
+		require.NoError(t, err)
+		heights = append(heights, parsed.Height.RevisionHeight)
+		trustedHeights = append(trustedHeights, h.TrustedHeight.RevisionHeight)
+	}
+	// the furthest header verifiable by set A is the last one signed by set B
+	require.Equal(t, []uint64{6, 8}, heights)
+	require.Equal(t, []uint64{1, 6}, trustedHeights)
+	// the proofs are fetched only for the planned headers
+	require.Equal(t, []uint64{6, 8}, source.proofs)
+	for _, h := range plan {
+		require.NotEmpty(t, h.AccountStateProof)
+	}
+
+	// 3. A direct update needs only one header
+	plan, err = PlanUpdate(context.Background(), source, trustedHeight, trusted, 3)
+	require.NoError(t, err)
+	require.Len(t, plan, 1)
+
+	// 4. The client is already up to date
+	plan, err = PlanUpdate(context.Background(), source, trustedHeight, trusted, 1)
+	require.NoError(t, err)
+	require.Empty(t, plan)
+
+	// 5. No sequence exists if the validator set is replaced between adjacent heights
+	for height := uint64(2); height <= 6; height++ {
+		source.add(t, height, setC)
+	}
+	_, err = PlanUpdate(context.Background(), source, trustedHeight, trusted, 8)
+	require.Error(t, err)
+}
+
+func TestPlanUpdateWithFewerSeals(t *testing.T) {
+	a, _ := generateValidators(t, 4)
+	b, _ := generateValidators(t, 2)
+	c, _ := generateValidators(t, 2)
+	setA := a
+	setB := []*ecdsa.PrivateKey{a[0], a[1], b[0], b[1]}
+	setC := []*ecdsa.PrivateKey{b[0], b[1], c[0], c[1]}
+
+	source := &testHeaderSource{headers: make(map[uint64]*Header)}
+	for height := uint64(1); height <= 8; height++ {
+		keys := setA
+		if height >= 7 {
+			keys = setC
+		} else if height >= 4 {
+			keys = setB
+		}
+		source.add(t, height, keys, chains.WithMissingSeals(3))
+		// the trailing seal is omitted, so the consensus state created by the header has an empty validator
+		source.headers[height].Seals = source.headers[height].Seals[:3]
+	}
+	trusted := &ConsensusState{Timestamp: 1, Validators: addressBytes(setA)}
+	trustedHeight := client.Height{RevisionHeight: 1}
+
+	// 1. A header is verified by the consensus state created by a header with fewer seals if the contract can verify it
+	plan, err := PlanUpdate(context.Background(), source, trustedHeight, trusted, 3)
+	require.NoError(t, err)
+	require.Len(t, plan, 1)
+
+	// 2. The planner returns an error instead of panicking when the contract would revert in the middle of the plan
+	_, err = PlanUpdate(context.Background(), source, trustedHeight, trusted, 8)
+	require.ErrorContains(t, err, "toAddress_outOfBounds")
+}
+
+type testHeaderSource struct {
+	headers map[uint64]*Header
+	// proofs is the heights whose account state proofs were requested
+	proofs []uint64
+}
+
+func (s *testHeaderSource) add(t *testing.T, height uint64, keys []*ecdsa.PrivateKey, opts ...chains.HeaderOption) {
+	built, err := chains.BuildHeader(
+		&gethtypes.Header{Difficulty: big.NewInt(1), Number: new(big.Int).SetUint64(height), GasLimit: 1000000, Time: 100 + height},
+		keys, 0, nil, opts...,
+	)
+	require.NoError(t, err)
+	besuHeaderRLP, err := built.GetSealingHeaderBytes()
+	require.NoError(t, err)
+	seals, err := built.ValidateAndGetCommitSeals()
+	require.NoError(t, err)
+	s.headers[height] = &Header{BesuHeaderRlp: besuHeaderRLP, Seals: seals}
+}
+
+func (s *testHeaderSource) withTrustedHeight(height uint64, trustedHeight client.Height) *Header {
+	h := *s.headers[height]
+	h.TrustedHeight = trustedHeight
+	return &h
+}
+
+func (s *testHeaderSource) GetHeader(ctx context.Context, height uint64) (*Header, error) {
+	h, ok := s.headers[height]
+	if !ok {
+		return nil, fmt.Errorf("header not found: %v", height)
+	}
+	return h, nil
+}
+
+func (s *testHeaderSource) GetAccountStateProof(ctx context.Context, height uint64) ([]byte, error) {
+	if _, ok := s.headers[height]; !ok {
+		return nil, fmt.Errorf("header not found: %v", height)
+	}
+	s.proofs = append(s.proofs, height)
+	return []byte{byte(height)}, nil
+}
+
+func addressBytes(keys []*ecdsa.PrivateKey) [][]byte {
+	var vals [][]byte
+	for _, addr := range chains.SignerAddresses(keys) {
+		vals = append(vals, addr.Bytes())
+	}
+	return vals
+}
@@ -0,0 +1,274 @@
+package ibft2
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	ethclient "github.com/hyperledger-labs/yui-ibc-solidity/pkg/client"
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/ibc/core/client"
+)
+
+// This file is a reference implementation of the header verification of IBFT2Client.sol.
+// The functions must be kept consistent with the contract to predict the result of `updateClient`.
+
+const (
+	besuHeaderItems  = 15
+	besuExtraItems   = 4
+	maxSuccessCount  = 255 // the success counters are uint8 in the contract
+	sealLength       = 65
+	stateRootIndex   = 3
+	numberIndex      = 8
+	timestampIndex   = 11
+	extraDataIndex   = 12
+	validatorsIndex  = 1
+	addressByteCount = 20
+)
+
+var secp256k1HalfN = new(big.Int).Rsh(crypto.S256().Params().N, 1)
+
+// ParsedBesuHeader is a header parsed like `parseBesuHeader` of the contract
+type ParsedBesuHeader struct {
+	Hash       common.Hash
+	StateRoot  common.Hash
+	Height     client.Height
+	Time       uint64
+	Validators [][]byte
+}
+
+// ParseBesuHeader parses the RLP encoded sealing header of Besu
+func ParseBesuHeader(besuHeaderRLP []byte) (*ParsedBesuHeader, error) {
+	var items []rlp.RawValue
+	if err := rlp.DecodeBytes(besuHeaderRLP, &items); err != nil {
+		return nil, err
+	} else if len(items) != besuHeaderItems {
+		return nil, fmt.Errorf("items length must be %v: %v", besuHeaderItems, len(items))
+	}
+	var (
+		parsed    = ParsedBesuHeader{Hash: crypto.Keccak256Hash(besuHeaderRLP)}
+		stateRoot []byte
+		number    uint64
+		extra     []byte
+		extraList []rlp.RawValue
+	)
+	if err := rlp.DecodeBytes(items[stateRootIndex], &stateRoot); err != nil {
+		return nil, err
+	}
+	parsed.StateRoot = common.BytesToHash(stateRoot)
+	if err := rlp.DecodeBytes(items[numberIndex], &number); err != nil {
+		return nil, err
+	}
+	parsed.Height = client.Height{RevisionNumber: 0, RevisionHeight: number}
+	if err := rlp.DecodeBytes(items[timestampIndex], &parsed.Time); err != nil {
+		return nil, err
+	}
+	if err := rlp.DecodeBytes(items[extraDataIndex], &extra); err != nil {
+		return nil, err
+	}
+	if err := rlp.DecodeBytes(extra, &extraList); err != nil {
+		return nil, err
+	} else if len(extraList) != besuExtraItems {
+		return nil, fmt.Errorf("extra length must be %v: %v", besuExtraItems, len(extraList))
+	}
+	if err := rlp.DecodeBytes(extraList[validatorsIndex], &parsed.Validators); err != nil {
+		return nil, err
+	}
+	for i, val := range parsed.Validators {
+		if len(val) < addressByteCount {
+			return nil, fmt.Errorf("invalid length of validator %v: %v", i, len(val))
+		}
+	}
+	return &parsed, nil
+}
+
+// VerifyResult is the result of the header verification
+type VerifyResult struct {
+	Header *ParsedBesuHeader
+	// TrustedSigners is the number of the trusted validators that signed the header
+	TrustedSigners int
+	// Signers is the number of the new validators that signed the header
+	Signers int
+	// Validators are the validators stored in the new consensus state
+	Validators [][]byte
+}
+
+// VerifyHeader verifies the header against the trusted consensus state in the same way as `updateClient` of the contract except the account proof.
+// It returns an error if the contract would reject or revert the update.
+func VerifyHeader(trusted *ConsensusState, header *Header) (*VerifyResult, error) {
+	if trusted == nil || trusted.Timestamp == 0 {
+		return nil, errors.New("trusted consensus state not found")
+	}
+	parsed, err := ParseBesuHeader(header.BesuHeaderRlp)
+	if err != nil {
+		return nil, err
+	}
+	if !heightGT(parsed.Height, header.TrustedHeight) {
+		return nil, fmt.Errorf("header height <= consensus state height: %v <= %v", parsed.Height, header.TrustedHeight)
+	}
+	result := &VerifyResult{Header: parsed}
+	result.TrustedSigners, err = verifyCommitSealsTrusting(trusted.Validators, header.Seals, parsed.Hash)
+	if err != nil {
+		return nil, err
+	}
+	// trustLevel = 1/3
+	if threshold := len(trusted.Validators) * 1 / 3; result.TrustedSigners < threshold {
+		return nil, fmt.Errorf("insufficient trusted signers: %v < %v", result.TrustedSigners, threshold)
+	}
+	result.Validators, result.Signers, err = verifyCommitSeals(parsed.Validators, header.Seals, parsed.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if threshold := len(parsed.Validators) * 2 / 3; result.Signers <= threshold {
+		return nil, fmt.Errorf("insufficient signers: %v <= %v", result.Signers, threshold)
+	}
+	return result, nil
+}
+
+// VerifyHeaderAndGetConsensusState verifies the header and the account proof of IBCStore, and returns the consensus state stored by the contract.
+func VerifyHeaderAndGetConsensusState(clientState *ClientState, trusted *ConsensusState, header *Header) (*ConsensusState, error) {
+	result, err := VerifyHeader(trusted, header)
+	if err != nil {
+		return nil, err
+	}
+	account, err := ethclient.VerifyAccountProof(result.Header.StateRoot, common.BytesToAddress(clientState.IbcStoreAddress), header.AccountStateProof)
+	if err != nil {
+		return nil, err
+	}
+	return &ConsensusState{
+		Timestamp:  result.Header.Time,
+		Root:       account.Root.Bytes(),
+		Validators: result.Validators,
+	}, nil
+}
+
+// TrustedConsensusState is a consensus state stored at the height
+type TrustedConsensusState struct {
+	Height         client.Height
+	ConsensusState *ConsensusState
+}
+
+// SelectTrustedHeight returns the highest height whose consensus state can verify the header.
+// If no consensus state can verify it, it returns an error with the reason for each height.
+func SelectTrustedHeight(header *Header, candidates []TrustedConsensusState) (client.Height, error) {
+	sorted := append([]TrustedConsensusState{}, candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return heightGT(sorted[i].Height, sorted[j].Height)
+	})
+	var errs []error
+	for _, c := range sorted {
+		h := *header
+		h.TrustedHeight = c.Height
+		if _, err := VerifyHeader(c.ConsensusState, &h); err != nil {
+			errs = append(errs, fmt.Errorf("height=%v: %v", c.Height, err))
+			continue
+		}
+		return c.Height, nil
+	}
+	return client.Height{}, fmt.Errorf("no trusted height can verify the header: %v", errs)
+}
+
+// verifyCommitSealsTrusting returns the number of unique trusted validators that signed the header.
+func verifyCommitSealsTrusting(trustedVals [][]byte, seals [][]byte, blkHash common.Hash) (int, error) {
+	success := 0
+	marked := make([]bool, len(trustedVals))
+	for _, seal := range seals {
+		if len(seal) == 0 {
+			continue
+		}
+		signer, err := ecdsaRecover(blkHash, seal)
+		if err != nil {
+			return 0, err
+		}
+		for j, val := range trustedVals {
+			if marked[j] {
+				continue
+			}
+			// NOTE: a trusted validator without a seal is stored as empty bytes, and the contract reverts on it in `toAddress`
+			if len(val) < addressByteCount {
+				return 0, fmt.Errorf("toAddress_outOfBounds: trusted validator index=%v length=%v", j, len(val))
+			}
+			if common.BytesToAddress(val[:addressByteCount]) == signer {
+				success++
+				marked[j] = true
+			}
+		}
+	}
+	if success > maxSuccessCount {
+		return 0, fmt.Errorf("the success count overflows: %v", success)
+	}
+	return success, nil
+}
+
+// verifyCommitSeals returns the validators of the new consensus state and the number of the validators that signed the header.
+// The order of the seals must match the order of the validators.
+func verifyCommitSeals(untrustedVals [][]byte, seals [][]byte, blkHash common.Hash) ([][]byte, int, error) {
+	if len(seals) > len(untrustedVals) {
+		return nil, 0, fmt.Errorf("the number of seals exceeds the number of validators: %v > %v", len(seals), len(untrustedVals))
+	}
+	// NOTE: the contract only copies the validators that have a corresponding seal
+	validators := make([][]byte, len(untrustedVals))
+	success := 0
+	for i, seal := range seals {
+		validators[i] = untrustedVals[i]
+		if len(seal) == 0 {
+			continue
+		}
+		signer, err := ecdsaRecover(blkHash, seal)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(validators[i]) < addressByteCount {
+			return nil, 0, fmt.Errorf("toAddress_outOfBounds: validator index=%v length=%v", i, len(validators[i]))
+		}
+		if common.BytesToAddress(validators[i][:addressByteCount]) == signer {
+			success++
+		}
+	}
+	if success > maxSuccessCount {
+		return nil, 0, fmt.Errorf("the success count overflows: %v", success)
+	}
+	for i := range validators {
+		if validators[i] == nil {
+			validators[i] = []byte{}
+		}
+	}
+	return validators, success, nil
+}
+
+// ecdsaRecover returns the signer of the seal like OpenZeppelin's ECDSA.tryRecover. It returns the zero address if the seal is invalid.
+// It returns an error only if the contract reverts.
+func ecdsaRecover(hash common.Hash, seal []byte) (common.Address, error) {
+	if len(seal) != sealLength {
+		return common.Address{}, fmt.Errorf("sig length must be %v: %v", sealLength, len(seal))
+	}
+	v := seal[64]
+	if v < 27 {
+		v += 27
+	}
+	if v != 27 && v != 28 {
+		return common.Address{}, nil
+	}
+	if s := new(big.Int).SetBytes(seal[32:64]); s.Cmp(secp256k1HalfN) > 0 {
+		return common.Address{}, nil
+	}
+	sig := make([]byte, sealLength)
+	copy(sig, seal)
+	sig[64] = v - 27
+	pub, err := crypto.SigToPub(hash.Bytes(), sig)
+	if err != nil {
+		return common.Address{}, nil
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+func heightGT(a, b client.Height) bool {
+	if a.RevisionNumber != b.RevisionNumber {
+		return a.RevisionNumber > b.RevisionNumber
+	}
+	return a.RevisionHeight > b.RevisionHeight
+}
@@ -0,0 +1,103 @@
+package ibft2
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/chains"
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/ibc/core/client"
+)
+
+func TestVerifyHeader(t *testing.T) {
+	keys, vals := generateValidators(t, 4)
+	_, otherVals := generateValidators(t, 3)
+
+	// the seal of the last validator is missing
+	built, err := chains.BuildHeader(
+		&gethtypes.Header{Root: common.Hash{1}, Difficulty: big.NewInt(1), Number: big.NewInt(10), GasLimit: 1000000, Time: 100},
+		keys, 0, nil, chains.WithMissingSeals(3),
+	)
+	require.NoError(t, err)
+	besuHeaderRLP, err := built.GetSealingHeaderBytes()
+	require.NoError(t, err)
+	seals, err := built.ValidateAndGetCommitSeals()
+	require.NoError(t, err)
+	header := &Header{BesuHeaderRlp: besuHeaderRLP, Seals: seals, TrustedHeight: client.Height{RevisionHeight: 5}}
+	trusted := &ConsensusState{Timestamp: 1, Validators: vals}
+
+	// 1. Valid header
+	result, err := VerifyHeader(trusted, header)
+	require.NoError(t, err)
+	require.Equal(t, 3, result.TrustedSigners)
+	require.Equal(t, 3, result.Signers)
+	require.Equal(t, vals, result.Validators)
+	require.Equal(t, uint64(10), result.Header.Height.RevisionHeight)
+
+	// 2. More than 1/3 of the trusted validators must sign
+	_, err = VerifyHeader(&ConsensusState{Timestamp: 1, Validators: append(otherVals, vals[0])}, header)
+	require.NoError(t, err)
+	_, err = VerifyHeader(&ConsensusState{Timestamp: 1, Validators: otherVals}, header)
+	require.Error(t, err)
+
+	// 3. The order of the seals must match the order of the validators
+	_, err = VerifyHeader(trusted, &Header{BesuHeaderRlp: besuHeaderRLP, Seals: [][]byte{seals[1], seals[0], seals[2], nil}, TrustedHeight: header.TrustedHeight})
+	require.Error(t, err)
+
+	// 4. The header must be higher than the trusted height
+	_, err = VerifyHeader(trusted, &Header{BesuHeaderRlp: besuHeaderRLP, Seals: seals, TrustedHeight: client.Height{RevisionHeight: 10}})
+	require.Error(t, err)
+
+	// 5. Invalid seals revert
+	_, err = VerifyHeader(trusted, &Header{BesuHeaderRlp: besuHeaderRLP, Seals: [][]byte{{0x01}}, TrustedHeight: header.TrustedHeight})
+	require.Error(t, err)
+
+	// 6. Select the highest trusted height that can verify the header
+	height, err := SelectTrustedHeight(header, []TrustedConsensusState{
+		{Height: client.Height{RevisionHeight: 3}, ConsensusState: trusted},
+		{Height: client.Height{RevisionHeight: 7}, ConsensusState: &ConsensusState{Timestamp: 1, Validators: otherVals}},
+		{Height: client.Height{RevisionHeight: 5}, ConsensusState: trusted},
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), height.RevisionHeight)
+	_, err = SelectTrustedHeight(header, []TrustedConsensusState{
+		{Height: client.Height{RevisionHeight: 7}, ConsensusState: &ConsensusState{Timestamp: 1, Validators: otherVals}},
+	})
+	require.Error(t, err)
+
+	// 7. The consensus state created by a header with fewer seals than validators has an empty validator, which the contract can't trust
+	result, err = VerifyHeader(trusted, &Header{BesuHeaderRlp: besuHeaderRLP, Seals: seals[:3], TrustedHeight: header.TrustedHeight})
+	require.NoError(t, err)
+	require.Equal(t, []byte{}, result.Validators[3])
+	next, err := chains.BuildHeader(
+		&gethtypes.Header{Root: common.Hash{1}, Difficulty: big.NewInt(1), Number: big.NewInt(11), GasLimit: 1000000, Time: 101},
+		keys, 0, nil,
+	)
+	require.NoError(t, err)
+	nextRLP, err := next.GetSealingHeaderBytes()
+	require.NoError(t, err)
+	nextSeals, err := next.ValidateAndGetCommitSeals()
+	require.NoError(t, err)
+	_, err = VerifyHeader(
+		&ConsensusState{Timestamp: 1, Validators: result.Validators},
+		&Header{BesuHeaderRlp: nextRLP, Seals: nextSeals, TrustedHeight: client.Height{RevisionHeight: 10}},
+	)
+	require.ErrorContains(t, err, "toAddress_outOfBounds")
+}
+
+func generateValidators(t *testing.T, n int) ([]*ecdsa.PrivateKey, [][]byte) {
+	var keys []*ecdsa.PrivateKey
+	var vals [][]byte
+	for i := 0; i < n; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keys = append(keys, key)
+		vals = append(vals, crypto.PubkeyToAddress(key.PublicKey).Bytes())
+	}
+	return keys, vals
+}
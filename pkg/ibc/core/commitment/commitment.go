@@ -1,40 +1,42 @@
 package commitment
 
 import (
-	host "github.com/cosmos/ibc-go/v4/modules/core/24-host"
 	"github.com/cosmos/ibc-go/v4/modules/core/exported"
-	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // This value is determined by IBCHost.sol
 var ibcHostCommitmentSlot = [32]byte{} // uint256(0)
 
-// Slot calculator
+// Slot calculator with DefaultStoreLayout. Use StoreLayout if the IBC store has a different layout.
 
 func ClientStateCommitmentSlot(clientID string) string {
-	return CalculateCommitmentSlot(host.FullClientStateKey(clientID))
+	return DefaultStoreLayout.ClientStateCommitmentSlot(clientID)
 }
 
 func ConsensusStateCommitmentSlot(clientID string, height exported.Height) string {
-	return CalculateCommitmentSlot(host.FullConsensusStateKey(clientID, height))
+	return DefaultStoreLayout.ConsensusStateCommitmentSlot(clientID, height)
 }
 
 func ConnectionStateCommitmentSlot(connectionID string) string {
-	return CalculateCommitmentSlot(host.ConnectionKey(connectionID))
+	return DefaultStoreLayout.ConnectionStateCommitmentSlot(connectionID)
 }
 
 func ChannelStateCommitmentSlot(portID, channelID string) string {
-	return CalculateCommitmentSlot(host.ChannelKey(portID, channelID))
+	return DefaultStoreLayout.ChannelStateCommitmentSlot(portID, channelID)
 }
 
 func PacketCommitmentSlot(portID, channelID string, sequence uint64) string {
-	return CalculateCommitmentSlot(host.PacketCommitmentKey(portID, channelID, sequence))
+	return DefaultStoreLayout.PacketCommitmentSlot(portID, channelID, sequence)
 }
 
 func PacketAcknowledgementCommitmentSlot(portID, channelID string, sequence uint64) string {
-	return CalculateCommitmentSlot(host.PacketAcknowledgementKey(portID, channelID, sequence))
+	return DefaultStoreLayout.PacketAcknowledgementCommitmentSlot(portID, channelID, sequence)
+}
+
+func PacketReceiptCommitmentSlot(portID, channelID string, sequence uint64) string {
+	return DefaultStoreLayout.PacketReceiptCommitmentSlot(portID, channelID, sequence)
 }
 
 func CalculateCommitmentSlot(path []byte) string {
-	return crypto.Keccak256Hash(crypto.Keccak256Hash(path).Bytes(), ibcHostCommitmentSlot[:]).Hex()
+	return DefaultStoreLayout.CalculateCommitmentSlot(path)
 }
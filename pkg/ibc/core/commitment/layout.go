@@ -0,0 +1,147 @@
+package commitment
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	host "github.com/cosmos/ibc-go/v4/modules/core/24-host"
+	"github.com/cosmos/ibc-go/v4/modules/core/exported"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// CommitmentsLabel is the name of the commitments mapping in IBCStore.sol
+	CommitmentsLabel = "commitments"
+	// commitmentsType is the type of the commitments mapping in the storage layout
+	commitmentsType = "t_mapping(t_bytes32,t_bytes32)"
+)
+
+// DefaultStoreLayout is the layout of IBCStore.sol deployed without a proxy
+var DefaultStoreLayout = StoreLayout{CommitmentSlot: common.Hash(ibcHostCommitmentSlot)}
+
+// StoreLayout is the storage layout of the IBC store that determines the slots of the commitments
+type StoreLayout struct {
+	// CommitmentSlot is the base slot of the commitments mapping
+	CommitmentSlot common.Hash
+}
+
+// NewStoreLayout returns a StoreLayout whose commitments mapping is at the slot
+func NewStoreLayout(slot *big.Int) StoreLayout {
+	return StoreLayout{CommitmentSlot: common.BigToHash(slot)}
+}
+
+func (l StoreLayout) ClientStateCommitmentSlot(clientID string) string {
+	return l.CalculateCommitmentSlot(host.FullClientStateKey(clientID))
+}
+
+func (l StoreLayout) ConsensusStateCommitmentSlot(clientID string, height exported.Height) string {
+	return l.CalculateCommitmentSlot(host.FullConsensusStateKey(clientID, height))
+}
+
+func (l StoreLayout) ConnectionStateCommitmentSlot(connectionID string) string {
+	return l.CalculateCommitmentSlot(host.ConnectionKey(connectionID))
+}
+
+func (l StoreLayout) ChannelStateCommitmentSlot(portID, channelID string) string {
+	return l.CalculateCommitmentSlot(host.ChannelKey(portID, channelID))
+}
+
+func (l StoreLayout) PacketCommitmentSlot(portID, channelID string, sequence uint64) string {
+	return l.CalculateCommitmentSlot(host.PacketCommitmentKey(portID, channelID, sequence))
+}
+
+func (l StoreLayout) PacketAcknowledgementCommitmentSlot(portID, channelID string, sequence uint64) string {
+	return l.CalculateCommitmentSlot(host.PacketAcknowledgementKey(portID, channelID, sequence))
+}
+
+func (l StoreLayout) PacketReceiptCommitmentSlot(portID, channelID string, sequence uint64) string {
+	return l.CalculateCommitmentSlot(host.PacketReceiptKey(portID, channelID, sequence))
+}
+
+// CalculateCommitmentSlot returns the slot of the commitment of the path: `keccak256(keccak256(path) || commitmentSlot)`
+func (l StoreLayout) CalculateCommitmentSlot(path []byte) string {
+	return CalculateCommitmentSlotWithBase(path, l.CommitmentSlot)
+}
+
+// CalculateCommitmentSlotWithBase returns the slot of the commitment of the path in the mapping at the base slot
+func CalculateCommitmentSlotWithBase(path []byte, base common.Hash) string {
+	return crypto.Keccak256Hash(crypto.Keccak256Hash(path).Bytes(), base.Bytes()).Hex()
+}
+
+// StorageLayout is the storage layout output of the solidity compiler, e.g. `forge inspect OwnableIBCHandler storageLayout`
+type StorageLayout struct {
+	Storage []StorageLayoutEntry `json:"storage"`
+}
+
+// StorageLayoutEntry is a state variable in StorageLayout
+type StorageLayoutEntry struct {
+	Label    string `json:"label"`
+	Contract string `json:"contract"`
+	// Slot is a decimal string
+	Slot   string `json:"slot"`
+	Offset uint   `json:"offset"`
+	Type   string `json:"type"`
+}
+
+// ParseStorageLayout parses the storage layout. It accepts both the output of `forge inspect` and a forge artifact that has the `storageLayout` field.
+func ParseStorageLayout(bz []byte) (*StorageLayout, error) {
+	var artifact struct {
+		StorageLayout *StorageLayout `json:"storageLayout"`
+	}
+	if err := json.Unmarshal(bz, &artifact); err != nil {
+		return nil, err
+	} else if artifact.StorageLayout != nil {
+		return artifact.StorageLayout, nil
+	}
+	var layout StorageLayout
+	if err := json.Unmarshal(bz, &layout); err != nil {
+		return nil, err
+	} else if layout.Storage == nil {
+		return nil, fmt.Errorf("storage layout not found")
+	}
+	return &layout, nil
+}
+
+// LoadStorageLayout reads the storage layout from the file. See ParseStorageLayout.
+func LoadStorageLayout(path string) (*StorageLayout, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStorageLayout(bz)
+}
+
+// Slot returns the slot of the state variable
+func (l StorageLayout) Slot(label string) (*StorageLayoutEntry, *big.Int, error) {
+	var found *StorageLayoutEntry
+	for i, entry := range l.Storage {
+		if entry.Label != label {
+			continue
+		} else if found != nil {
+			return nil, nil, fmt.Errorf("ambiguous state variable: label=%v contracts=[%v %v]", label, found.Contract, entry.Contract)
+		}
+		found = &l.Storage[i]
+	}
+	if found == nil {
+		return nil, nil, fmt.Errorf("state variable not found: %v", label)
+	}
+	slot, ok := new(big.Int).SetString(found.Slot, 10)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid slot: label=%v slot=%v", label, found.Slot)
+	}
+	return found, slot, nil
+}
+
+// StoreLayout returns the StoreLayout of the contract whose IBC store is laid out as the storage layout
+func (l StorageLayout) StoreLayout() (StoreLayout, error) {
+	entry, slot, err := l.Slot(CommitmentsLabel)
+	if err != nil {
+		return StoreLayout{}, err
+	} else if entry.Type != commitmentsType {
+		return StoreLayout{}, fmt.Errorf("unexpected type of %v: %v", CommitmentsLabel, entry.Type)
+	}
+	return NewStoreLayout(slot), nil
+}
@@ -0,0 +1,57 @@
+package commitment
+
+import (
+	"math/big"
+	"testing"
+
+	host "github.com/cosmos/ibc-go/v4/modules/core/24-host"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+const testStorageLayout = `{
+  "storage": [
+    {"astId": 1, "contract": "contracts/UpgradeableIBCHandler.sol:UpgradeableIBCHandler", "label": "_initialized", "offset": 0, "slot": "0", "type": "t_uint8"},
+    {"astId": 2, "contract": "contracts/UpgradeableIBCHandler.sol:UpgradeableIBCHandler", "label": "__gap", "offset": 0, "slot": "1", "type": "t_array(t_uint256)50_storage"},
+    {"astId": 3, "contract": "contracts/UpgradeableIBCHandler.sol:UpgradeableIBCHandler", "label": "commitments", "offset": 0, "slot": "51", "type": "t_mapping(t_bytes32,t_bytes32)"},
+    {"astId": 4, "contract": "contracts/UpgradeableIBCHandler.sol:UpgradeableIBCHandler", "label": "clientRegistry", "offset": 0, "slot": "52", "type": "t_mapping(t_string_memory_ptr,t_address)"}
+  ],
+  "types": {}
+}`
+
+func TestStoreLayout(t *testing.T) {
+	path := host.PacketCommitmentKey("transfer", "channel-0", 1)
+
+	// 1. The default layout is the commitments mapping at slot 0
+	expected := crypto.Keccak256Hash(crypto.Keccak256Hash(path).Bytes(), common.Hash{}.Bytes()).Hex()
+	require.Equal(t, expected, CalculateCommitmentSlot(path))
+	require.Equal(t, expected, PacketCommitmentSlot("transfer", "channel-0", 1))
+
+	// 2. The base slot is read from the storage layout of forge
+	layout, err := ParseStorageLayout([]byte(testStorageLayout))
+	require.NoError(t, err)
+	storeLayout, err := layout.StoreLayout()
+	require.NoError(t, err)
+	require.Equal(t, NewStoreLayout(big.NewInt(51)), storeLayout)
+	require.Equal(t,
+		crypto.Keccak256Hash(crypto.Keccak256Hash(path).Bytes(), common.BigToHash(big.NewInt(51)).Bytes()).Hex(),
+		storeLayout.PacketCommitmentSlot("transfer", "channel-0", 1),
+	)
+
+	// 3. A forge artifact with the storage layout is also accepted
+	artifact, err := ParseStorageLayout([]byte(`{"abi": [], "storageLayout": ` + testStorageLayout + `}`))
+	require.NoError(t, err)
+	require.Equal(t, layout, artifact)
+
+	// 4. The commitments mapping must exist with the expected type
+	_, err = StorageLayout{Storage: layout.Storage[:2]}.StoreLayout()
+	require.Error(t, err)
+	invalid := *layout
+	invalid.Storage = append([]StorageLayoutEntry{}, layout.Storage...)
+	invalid.Storage[2].Type = "t_mapping(t_bytes32,t_uint256)"
+	_, err = invalid.StoreLayout()
+	require.Error(t, err)
+	_, err = ParseStorageLayout([]byte(`{"abi": []}`))
+	require.Error(t, err)
+}
@@ -2,7 +2,6 @@ package testing
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"crypto/sha256"
 	"errors"
 	"fmt"
@@ -10,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -22,6 +22,7 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/chains"
 	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/client"
 	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/contract/erc20"
 	ibccommitment "github.com/hyperledger-labs/yui-ibc-solidity/pkg/contract/ibccommitmenttesthelper"
@@ -67,11 +68,18 @@ func init() {
 type Chain struct {
 	t *testing.T
 
-	chainID  int64
-	client   *client.ETHClient
-	lc       *LightClient
-	mnemonic string
-	keys     map[uint32]*ecdsa.PrivateKey
+	chainID int64
+	client  *client.ETHClient
+	txMgr   *client.TxManager
+	lc      *LightClient
+	keyring wallet.Keyring
+	// mu protects signers from the concurrent senders of the key pool
+	mu      sync.Mutex
+	signers map[uint32]wallet.Signer
+	// keyPool is used by SendRelayerTx if it's set. See SetKeyPool.
+	keyPool *KeyPool
+	// simulation enables the pre-flight simulation mode. See SetSimulation.
+	simulation bool
 
 	ContractConfig ContractConfig
 
@@ -92,52 +100,70 @@ type Chain struct {
 	Connections []*TestConnection // track connectionID's created for this chain
 }
 
-func NewChain(t *testing.T, client *client.ETHClient, lc *LightClient) *Chain {
-	mnemonic := os.Getenv("TEST_MNEMONIC")
-	if mnemonic == "" {
-		t.Fatal("environ variable 'TEST_MNEMONIC' is empty")
+// NewChain returns a Chain whose accounts are loaded from the environ variables. See KeyringFromEnv.
+func NewChain(t *testing.T, ethClient *client.ETHClient, lc *LightClient) *Chain {
+	keyring, err := KeyringFromEnv()
+	if err != nil {
+		t.Fatal(err)
 	}
+	return NewChainWithKeyring(t, ethClient, lc, keyring)
+}
+
+// NewChainWithKeyring returns a Chain that signs transactions with the signers of the keyring
+func NewChainWithKeyring(t *testing.T, ethClient *client.ETHClient, lc *LightClient, keyring wallet.Keyring) *Chain {
 	logDir := os.Getenv("TEST_BROADCAST_LOG_DIR")
 	if logDir == "" {
 		t.Fatal("environ variable 'TEST_BROADCAST_LOG_DIR' is empty")
 	}
-	chainID, err := client.ChainID(context.TODO())
+	chainID, err := ethClient.ChainID(context.TODO())
 	if err != nil {
 		t.Fatal(err)
 	}
+	txMgr := client.NewTxManager(ethClient, chainID)
 	config, err := buildContractConfigFromBroadcastLog(filepath.Join(logDir, chainID.String(), "run-latest.json"))
 	if err != nil {
 		t.Fatal(err)
 	}
-	ibcHandler, err := ibchandler.NewIbchandler(config.IBCHandlerAddress, client)
+	// the storage layout is required if IBCHandler has a custom layout, e.g. `forge inspect OwnableIBCHandler storageLayout`
+	if path := os.Getenv("TEST_STORAGE_LAYOUT"); path != "" {
+		layout, err := commitment.LoadStorageLayout(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.StoreLayout, err = layout.StoreLayout(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ibcHandler, err := ibchandler.NewIbchandler(config.IBCHandlerAddress, txMgr)
 	if err != nil {
 		t.Fatal(err)
 	}
-	ibcCommitment, err := ibccommitment.NewIbccommitmenttesthelper(config.IBCCommitmentTestHelperAddress, client)
+	ibcCommitment, err := ibccommitment.NewIbccommitmenttesthelper(config.IBCCommitmentTestHelperAddress, txMgr)
 	if err != nil {
 		t.Fatal(err)
 	}
-	erc20_, err := erc20.NewErc20(config.ERC20TokenAddress, client)
+	erc20_, err := erc20.NewErc20(config.ERC20TokenAddress, txMgr)
 	if err != nil {
 		t.Fatal(err)
 	}
-	ics20transfer, err := ics20transferbank.NewIcs20transferbank(config.ICS20TransferBankAddress, client)
+	ics20transfer, err := ics20transferbank.NewIcs20transferbank(config.ICS20TransferBankAddress, txMgr)
 	if err != nil {
 		t.Fatal(err)
 	}
-	ics20bank, err := ics20bank.NewIcs20bank(config.ICS20BankAddress, client)
+	ics20bank, err := ics20bank.NewIcs20bank(config.ICS20BankAddress, txMgr)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	return &Chain{
 		t:              t,
-		client:         client,
+		client:         ethClient,
+		txMgr:          txMgr,
 		chainID:        chainID.Int64(),
 		lc:             lc,
-		mnemonic:       mnemonic,
+		keyring:        keyring,
 		ContractConfig: *config,
-		keys:           make(map[uint32]*ecdsa.PrivateKey),
+		signers:        make(map[uint32]wallet.Signer),
 
 		IBCHandler:    *ibcHandler,
 		IBCCommitment: *ibcCommitment,
@@ -152,12 +178,16 @@ func (chain *Chain) Client() *client.ETHClient {
 	return chain.client
 }
 
+func (chain *Chain) TxManager() *client.TxManager {
+	return chain.txMgr
+}
+
 func (chain *Chain) ClientType() string {
 	return chain.lc.ClientType()
 }
 
 func (chain *Chain) TxOpts(ctx context.Context, index uint32) *bind.TransactOpts {
-	return makeGenTxOpts(big.NewInt(chain.chainID), chain.prvKey(index))(ctx)
+	return makeGenTxOpts(chain.txMgr, big.NewInt(chain.chainID), chain.signer(index))(ctx)
 }
 
 func (chain *Chain) CallOpts(ctx context.Context, index uint32) *bind.CallOpts {
@@ -168,17 +198,19 @@ func (chain *Chain) CallOpts(ctx context.Context, index uint32) *bind.CallOpts {
 	}
 }
 
-func (chain *Chain) prvKey(index uint32) *ecdsa.PrivateKey {
-	key, ok := chain.keys[index]
+func (chain *Chain) signer(index uint32) wallet.Signer {
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	signer, ok := chain.signers[index]
 	if ok {
-		return key
+		return signer
 	}
-	key, err := wallet.GetPrvKeyFromMnemonicAndHDWPath(chain.mnemonic, fmt.Sprintf("m/44'/60'/0'/0/%v", index))
+	signer, err := chain.keyring.Signer(index)
 	if err != nil {
 		panic(err)
 	}
-	chain.keys[index] = key
-	return key
+	chain.signers[index] = signer
+	return signer
 }
 
 func (chain *Chain) ChainID() int64 {
@@ -340,28 +372,127 @@ func (chain *Chain) ConstructIBFT2MsgUpdateClient(counterparty *Chain, clientID
 	}
 }
 
+// ConstructIBFT2MsgUpdateClients constructs the messages to update the IBFT2 client to the latest header of the counterparty.
+// The intermediate headers are selected by ibft2clienttypes.PlanUpdate, so each message satisfies the trust threshold of the previous one.
+// If the client is already up to date, no message is returned.
+func (chain *Chain) ConstructIBFT2MsgUpdateClients(ctx context.Context, counterparty *Chain, clientID string) ([]ibchandler.IBCMsgsMsgUpdateClient, error) {
+	trustedHeight := chain.GetIBFT2ClientState(clientID).LatestHeight
+	headers, err := ibft2clienttypes.PlanUpdate(
+		ctx,
+		ibft2HeaderSource{chain: counterparty},
+		trustedHeight,
+		chain.GetIBFT2ConsensusState(clientID, trustedHeight),
+		counterparty.LastHeader().Number.Uint64(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var msgs []ibchandler.IBCMsgsMsgUpdateClient
+	for _, header := range headers {
+		bz, err := MarshalWithAny(header)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, ibchandler.IBCMsgsMsgUpdateClient{
+			ClientId:      clientID,
+			ClientMessage: bz,
+		})
+	}
+	return msgs, nil
+}
+
+// ibft2HeaderSource provides the IBFT2 headers of the chain to the bisection planner
+type ibft2HeaderSource struct {
+	chain *Chain
+}
+
+var _ ibft2clienttypes.HeaderSource = (*ibft2HeaderSource)(nil)
+
+func (s ibft2HeaderSource) GetHeader(ctx context.Context, height uint64) (*ibft2clienttypes.Header, error) {
+	var cs IBFT2State
+	if last, ok := s.chain.LastLCState.(IBFT2State); ok && last.Header().Number.Uint64() == height {
+		cs = last
+	} else {
+		var err error
+		if cs, err = s.chain.lc.GetIBFT2Header(ctx, client.BlockRef(new(big.Int).SetUint64(height))); err != nil {
+			return nil, err
+		}
+	}
+	return &ibft2clienttypes.Header{
+		BesuHeaderRlp: cs.SealingHeaderRLP(),
+		Seals:         cs.CommitSeals,
+	}, nil
+}
+
+func (s ibft2HeaderSource) GetAccountStateProof(ctx context.Context, height uint64) ([]byte, error) {
+	if last, ok := s.chain.LastLCState.(IBFT2State); ok && last.Header().Number.Uint64() == height {
+		return last.Proof().AccountProofRLP, nil
+	}
+	proof, err := s.chain.client.GetProofBatch(ctx, s.chain.ContractConfig.IBCHandlerAddress, nil, client.BlockRef(new(big.Int).SetUint64(height)))
+	if err != nil {
+		return nil, err
+	}
+	return proof.AccountProofRLP, nil
+}
+
+// VerifyIBFT2Header predicts whether `UpdateClient` of the IBFT2 client succeeds with the message constructed by ConstructIBFT2MsgUpdateClient.
+func (chain *Chain) VerifyIBFT2Header(counterparty *Chain, clientID string) error {
+	msg := chain.ConstructIBFT2MsgUpdateClient(counterparty, clientID)
+	var header ibft2clienttypes.Header
+	if err := UnmarshalWithAny(msg.ClientMessage, &header); err != nil {
+		return err
+	}
+	_, err := ibft2clienttypes.VerifyHeaderAndGetConsensusState(
+		chain.GetIBFT2ClientState(clientID),
+		chain.GetIBFT2ConsensusState(clientID, header.TrustedHeight),
+		&header,
+	)
+	return err
+}
+
+// NewMisbehaviourDetector creates a detector for the headers of the chain with the consensus type of the light client
+func (chain *Chain) NewMisbehaviourDetector() *chains.MisbehaviourDetector {
+	return chains.NewMisbehaviourDetector(chain.lc.Consensus())
+}
+
+// CheckIBFT2ConsensusState checks that the root stored in the consensus state of the IBFT2 client at the height matches the storage root of IBCStore on the counterparty.
+// The header of the counterparty is added to the detector, so it also returns the evidences of conflicts with the headers seen before.
+func (chain *Chain) CheckIBFT2ConsensusState(counterparty *Chain, clientID string, height ibcclient.Height, detector *chains.MisbehaviourDetector) (*chains.RootConflict, []*chains.Evidence, error) {
+	stored := chain.GetIBFT2ConsensusState(clientID, height)
+	state, err := counterparty.lc.GetState(context.Background(), counterparty.ContractConfig.IBCHandlerAddress, nil, height.ToBN())
+	if err != nil {
+		return nil, nil, err
+	}
+	storageRoot, err := client.VerifyStateProof(state.Header().Root, counterparty.ContractConfig.IBCHandlerAddress, state.Proof())
+	if err != nil {
+		return nil, nil, err
+	}
+	return detector.CheckStoredRoot(state.Header(), storageRoot, stored.Root)
+}
+
 func (chain *Chain) UpdateHeader() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	for {
-		state, err := chain.lc.GetState(ctx, chain.ContractConfig.IBCHandlerAddress, nil, nil)
+	var height *big.Int
+	if chain.LastLCState != nil {
+		header, err := chain.client.WaitForNewHead(ctx, chain.LastHeader().Number)
 		if err != nil {
 			panic(err)
 		}
-		if chain.LastLCState == nil || state.Header().Number.Cmp(chain.LastHeader().Number) == 1 {
-			chain.LastLCState = state
-			return
-		} else {
-			continue
-		}
+		height = header.Number
 	}
+	state, err := chain.lc.GetState(ctx, chain.ContractConfig.IBCHandlerAddress, nil, height)
+	if err != nil {
+		panic(err)
+	}
+	chain.LastLCState = state
 }
 
 func (chain *Chain) CreateMockClient(ctx context.Context, counterparty *Chain) (string, error) {
 	msg := chain.ConstructMockMsgCreateClient(counterparty)
-	if err := chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.CreateClient(chain.TxOpts(ctx, RelayerKeyIndex), msg),
-	); err != nil {
+	if err := chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.CreateClient(opts, msg)
+	}); err != nil {
 		return "", err
 	}
 	return chain.GetLastGeneratedClientID(ctx)
@@ -369,32 +500,42 @@ func (chain *Chain) CreateMockClient(ctx context.Context, counterparty *Chain) (
 
 func (chain *Chain) UpdateMockClient(ctx context.Context, counterparty *Chain, clientID string) error {
 	msg := chain.ConstructMockMsgUpdateClient(counterparty, clientID)
-	return chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.UpdateClient(chain.TxOpts(ctx, RelayerKeyIndex), msg),
-	)
+	return chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.UpdateClient(opts, msg)
+	})
 }
 
 func (chain *Chain) CreateIBFT2Client(ctx context.Context, counterparty *Chain) (string, error) {
 	msg := chain.ConstructIBFT2MsgCreateClient(counterparty)
-	if err := chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.CreateClient(chain.TxOpts(ctx, RelayerKeyIndex), msg),
-	); err != nil {
+	if err := chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.CreateClient(opts, msg)
+	}); err != nil {
 		return "", err
 	}
 	return chain.GetLastGeneratedClientID(ctx)
 }
 
+// UpdateIBFT2Client updates the IBFT2 client to the latest header of the counterparty.
+// If the validator set changes too much to verify the header directly, it submits the intermediate headers planned by bisection in order.
 func (chain *Chain) UpdateIBFT2Client(ctx context.Context, counterparty *Chain, clientID string) error {
-	msg := chain.ConstructIBFT2MsgUpdateClient(counterparty, clientID)
-	return chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.UpdateClient(chain.TxOpts(ctx, RelayerKeyIndex), msg),
-	)
+	msgs, err := chain.ConstructIBFT2MsgUpdateClients(ctx, counterparty, clientID)
+	if err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		if err := chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+			return chain.IBCHandler.UpdateClient(opts, msg)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (chain *Chain) ConnectionOpenInit(ctx context.Context, counterparty *Chain, connection, counterpartyConnection *TestConnection) (string, error) {
-	if err := chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.ConnectionOpenInit(
-			chain.TxOpts(ctx, RelayerKeyIndex),
+	if err := chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.ConnectionOpenInit(
+			opts,
 			ibchandler.IBCMsgsMsgConnectionOpenInit{
 				ClientId: connection.ClientID,
 				Counterparty: ibchandler.CounterpartyData{
@@ -404,8 +545,8 @@ func (chain *Chain) ConnectionOpenInit(ctx context.Context, counterparty *Chain,
 				},
 				DelayPeriod: DefaultDelayPeriod,
 			},
-		),
-	); err != nil {
+		)
+	}); err != nil {
 		return "", err
 	}
 	return chain.GetLastGeneratedConnectionID(ctx)
@@ -420,9 +561,9 @@ func (chain *Chain) ConnectionOpenTry(ctx context.Context, counterparty *Chain,
 	if err != nil {
 		return "", err
 	}
-	if err := chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.ConnectionOpenTry(
-			chain.TxOpts(ctx, RelayerKeyIndex),
+	if err := chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.ConnectionOpenTry(
+			opts,
 			ibchandler.IBCMsgsMsgConnectionOpenTry{
 				Counterparty: ibchandler.CounterpartyData{
 					ClientId:     counterpartyConnection.ClientID,
@@ -439,8 +580,8 @@ func (chain *Chain) ConnectionOpenTry(ctx context.Context, counterparty *Chain,
 				ProofInit:   proofConnection.Data,
 				ProofClient: proofClient.Data,
 			},
-		),
-	); err != nil {
+		)
+	}); err != nil {
 		return "", err
 	}
 	return chain.GetLastGeneratedConnectionID(ctx)
@@ -460,9 +601,9 @@ func (chain *Chain) ConnectionOpenAck(
 	if err != nil {
 		return err
 	}
-	return chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.ConnectionOpenAck(
-			chain.TxOpts(ctx, RelayerKeyIndex),
+	return chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.ConnectionOpenAck(
+			opts,
 			ibchandler.IBCMsgsMsgConnectionOpenAck{
 				ConnectionId:             connection.ID,
 				CounterpartyConnectionID: counterpartyConnection.ID,
@@ -472,8 +613,8 @@ func (chain *Chain) ConnectionOpenAck(
 				ProofTry:                 proofConnection.Data,
 				ProofClient:              proofClient.Data,
 			},
-		),
-	)
+		)
+	})
 }
 
 func (chain *Chain) ConnectionOpenConfirm(
@@ -485,16 +626,16 @@ func (chain *Chain) ConnectionOpenConfirm(
 	if err != nil {
 		return err
 	}
-	return chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.ConnectionOpenConfirm(
-			chain.TxOpts(ctx, RelayerKeyIndex),
+	return chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.ConnectionOpenConfirm(
+			opts,
 			ibchandler.IBCMsgsMsgConnectionOpenConfirm{
 				ConnectionId: connection.ID,
 				ProofAck:     proof.Data,
 				ProofHeight:  proof.Height.ToCallData(),
 			},
-		),
-	)
+		)
+	})
 }
 
 func (chain *Chain) ChannelOpenInit(
@@ -503,9 +644,9 @@ func (chain *Chain) ChannelOpenInit(
 	order channeltypes.Channel_Order,
 	connectionID string,
 ) (string, error) {
-	if err := chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.ChannelOpenInit(
-			chain.TxOpts(ctx, RelayerKeyIndex),
+	if err := chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.ChannelOpenInit(
+			opts,
 			ibchandler.IBCMsgsMsgChannelOpenInit{
 				PortId: ch.PortID,
 				Channel: ibchandler.ChannelData{
@@ -519,8 +660,8 @@ func (chain *Chain) ChannelOpenInit(
 					Version:        ch.Version,
 				},
 			},
-		),
-	); err != nil {
+		)
+	}); err != nil {
 		return "", err
 	}
 	return chain.GetLastGeneratedChannelID(ctx)
@@ -537,9 +678,9 @@ func (chain *Chain) ChannelOpenTry(
 	if err != nil {
 		return "", err
 	}
-	if err := chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.ChannelOpenTry(
-			chain.TxOpts(ctx, RelayerKeyIndex),
+	if err := chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.ChannelOpenTry(
+			opts,
 			ibchandler.IBCMsgsMsgChannelOpenTry{
 				PortId: ch.PortID,
 				Channel: ibchandler.ChannelData{
@@ -556,8 +697,8 @@ func (chain *Chain) ChannelOpenTry(
 				ProofInit:           proof.Data,
 				ProofHeight:         proof.Height.ToCallData(),
 			},
-		),
-	); err != nil {
+		)
+	}); err != nil {
 		return "", err
 	}
 	return chain.GetLastGeneratedChannelID(ctx)
@@ -572,9 +713,9 @@ func (chain *Chain) ChannelOpenAck(
 	if err != nil {
 		return err
 	}
-	return chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.ChannelOpenAck(
-			chain.TxOpts(ctx, RelayerKeyIndex),
+	return chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.ChannelOpenAck(
+			opts,
 			ibchandler.IBCMsgsMsgChannelOpenAck{
 				PortId:                ch.PortID,
 				ChannelId:             ch.ID,
@@ -583,8 +724,8 @@ func (chain *Chain) ChannelOpenAck(
 				ProofTry:              proof.Data,
 				ProofHeight:           proof.Height.ToCallData(),
 			},
-		),
-	)
+		)
+	})
 }
 
 func (chain *Chain) ChannelOpenConfirm(
@@ -596,32 +737,32 @@ func (chain *Chain) ChannelOpenConfirm(
 	if err != nil {
 		return err
 	}
-	return chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.ChannelOpenConfirm(
-			chain.TxOpts(ctx, RelayerKeyIndex),
+	return chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.ChannelOpenConfirm(
+			opts,
 			ibchandler.IBCMsgsMsgChannelOpenConfirm{
 				PortId:      ch.PortID,
 				ChannelId:   ch.ID,
 				ProofAck:    proof.Data,
 				ProofHeight: proof.Height.ToCallData(),
 			},
-		),
-	)
+		)
+	})
 }
 
 func (chain *Chain) ChannelCloseInit(
 	ctx context.Context,
 	ch TestChannel,
 ) error {
-	return chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.ChannelCloseInit(
-			chain.TxOpts(ctx, RelayerKeyIndex),
+	return chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.ChannelCloseInit(
+			opts,
 			ibchandler.IBCMsgsMsgChannelCloseInit{
 				PortId:    ch.PortID,
 				ChannelId: ch.ID,
 			},
-		),
-	)
+		)
+	})
 }
 
 func (chain *Chain) ChannelCloseConfirm(
@@ -633,33 +774,33 @@ func (chain *Chain) ChannelCloseConfirm(
 	if err != nil {
 		return err
 	}
-	return chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.ChannelCloseConfirm(
-			chain.TxOpts(ctx, RelayerKeyIndex),
+	return chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.ChannelCloseConfirm(
+			opts,
 			ibchandler.IBCMsgsMsgChannelCloseConfirm{
 				PortId:      ch.PortID,
 				ChannelId:   ch.ID,
 				ProofInit:   proof.Data,
 				ProofHeight: proof.Height.ToCallData(),
 			},
-		),
-	)
+		)
+	})
 }
 
 func (chain *Chain) SendPacket(
 	ctx context.Context,
 	packet channeltypes.Packet,
 ) error {
-	return chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.SendPacket(
-			chain.TxOpts(ctx, RelayerKeyIndex),
+	return chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.SendPacket(
+			opts,
 			packet.SourcePort,
 			packet.SourceChannel,
 			ibchandler.HeightData(packet.TimeoutHeight),
 			packet.TimeoutTimestamp,
 			packet.Data,
-		),
-	)
+		)
+	})
 }
 
 func (chain *Chain) HandlePacketRecv(
@@ -668,7 +809,7 @@ func (chain *Chain) HandlePacketRecv(
 	ch, counterpartyCh TestChannel,
 	packet channeltypes.Packet,
 ) error {
-	proof, err := counterparty.QueryProof(chain, ch.ClientID, commitment.PacketCommitmentSlot(packet.SourcePort, packet.SourceChannel, packet.Sequence), nil)
+	proof, err := counterparty.QueryProof(chain, ch.ClientID, counterparty.ContractConfig.StoreLayout.PacketCommitmentSlot(packet.SourcePort, packet.SourceChannel, packet.Sequence), nil)
 	if err != nil {
 		return err
 	}
@@ -677,16 +818,16 @@ func (chain *Chain) HandlePacketRecv(
 		h := sha256.Sum256(commitPacket(packet))
 		proof.Data = h[:]
 	}
-	return chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.RecvPacket(
-			chain.TxOpts(ctx, RelayerKeyIndex),
+	return chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.RecvPacket(
+			opts,
 			ibchandler.IBCMsgsMsgPacketRecv{
 				Packet:      packetToCallData(packet),
 				Proof:       proof.Data,
 				ProofHeight: proof.Height.ToCallData(),
 			},
-		),
-	)
+		)
+	})
 }
 
 func (chain *Chain) HandlePacketAcknowledgement(
@@ -696,7 +837,7 @@ func (chain *Chain) HandlePacketAcknowledgement(
 	packet channeltypes.Packet,
 	acknowledgement []byte,
 ) error {
-	proof, err := counterparty.QueryProof(chain, ch.ClientID, commitment.PacketAcknowledgementCommitmentSlot(packet.DestinationPort, packet.DestinationChannel, packet.Sequence), nil)
+	proof, err := counterparty.QueryProof(chain, ch.ClientID, counterparty.ContractConfig.StoreLayout.PacketAcknowledgementCommitmentSlot(packet.DestinationPort, packet.DestinationChannel, packet.Sequence), nil)
 	if err != nil {
 		return err
 	}
@@ -705,17 +846,17 @@ func (chain *Chain) HandlePacketAcknowledgement(
 		h := sha256.Sum256(commitAcknowledgement(acknowledgement))
 		proof.Data = h[:]
 	}
-	return chain.WaitIfNoError(ctx)(
-		chain.IBCHandler.AcknowledgePacket(
-			chain.TxOpts(ctx, RelayerKeyIndex),
+	return chain.SendRelayerTx(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return chain.IBCHandler.AcknowledgePacket(
+			opts,
 			ibchandler.IBCMsgsMsgPacketAcknowledgement{
 				Packet:          packetToCallData(packet),
 				Acknowledgement: acknowledgement,
 				Proof:           proof.Data,
 				ProofHeight:     proof.Height.ToCallData(),
 			},
-		),
-	)
+		)
+	})
 }
 
 func (chain *Chain) GetLastGeneratedClientID(
@@ -853,20 +994,115 @@ func packetToCallData(packet channeltypes.Packet) ibchandler.PacketData {
 type Proof struct {
 	Height ibcclient.Height
 	Data   []byte
+	// Storage is the proven storage slot. It is nil if the light client doesn't provide storage proofs.
+	Storage *client.StorageProof
+}
+
+// VerifyCommitment checks if the proven value equals to keccak256(commitment) that IBCStore should hold
+func (p Proof) VerifyCommitment(commitment []byte) error {
+	if p.Storage == nil {
+		return errors.New("storage proof not found")
+	} else if !p.Storage.Exists {
+		return fmt.Errorf("slot not found: %v", p.Storage.Key)
+	} else if expected := gethcrypto.Keccak256Hash(commitment); p.Storage.Value != expected {
+		return fmt.Errorf("commitment mismatch: expected=%v actual=%v", expected, p.Storage.Value)
+	}
+	return nil
 }
 
 func (chain *Chain) QueryProof(counterparty *Chain, counterpartyClientID string, storageKey string, height *big.Int) (*Proof, error) {
-	if !strings.HasPrefix(storageKey, "0x") {
-		return nil, fmt.Errorf("storageKey must be hex string")
+	proofs, err := chain.QueryProofs(counterparty, counterpartyClientID, []string{storageKey}, height)
+	if err != nil {
+		return nil, err
 	}
-	s, err := chain.GetLightClientState(counterparty, counterpartyClientID, [][]byte{[]byte(storageKey)}, height)
+	return proofs[0], nil
+}
+
+// QueryProofs returns the proofs of the storage keys at the same height.
+// The storage proofs are queried by one `eth_getProof` call or a JSON-RPC batch. See client.ETHClient.GetProofBatch.
+func (chain *Chain) QueryProofs(counterparty *Chain, counterpartyClientID string, storageKeys []string, height *big.Int) ([]*Proof, error) {
+	keys := make([][]byte, len(storageKeys))
+	for i, storageKey := range storageKeys {
+		if !strings.HasPrefix(storageKey, "0x") {
+			return nil, fmt.Errorf("storageKey must be hex string")
+		}
+		keys[i] = []byte(storageKey)
+	}
+	s, err := chain.GetLightClientState(counterparty, counterpartyClientID, keys, height)
 	if err != nil {
 		return nil, err
 	}
-	return &Proof{
-		Height: ibcclient.NewHeightFromBN(s.Header().Number),
-		Data:   s.Proof().StorageProofRLP[0],
-	}, nil
+	proofHeight := ibcclient.NewHeightFromBN(s.Header().Number)
+	sps := s.Proof().StorageProofs
+	proofs := make([]*Proof, len(storageKeys))
+	for i := range storageKeys {
+		proofs[i] = &Proof{
+			Height: proofHeight,
+			Data:   s.Proof().StorageProofRLP[i],
+		}
+		if len(sps) > 0 {
+			proofs[i].Storage = &sps[i]
+		}
+	}
+	return proofs, nil
+}
+
+// QueryPacketProofs returns the proofs of the packet commitments at the same height.
+func (counterparty *Chain) QueryPacketProofs(chain *Chain, counterpartyClientID string, packets []channeltypes.Packet, height *big.Int) ([]*Proof, error) {
+	var slots []string
+	for _, packet := range packets {
+		slots = append(slots, counterparty.ContractConfig.StoreLayout.PacketCommitmentSlot(packet.SourcePort, packet.SourceChannel, packet.Sequence))
+	}
+	proofs, err := counterparty.QueryProofs(chain, counterpartyClientID, slots, height)
+	if err != nil {
+		return nil, err
+	}
+	switch chain.ClientType() {
+	case ibcclient.MockClient:
+		for i, packet := range packets {
+			h := sha256.Sum256(commitPacket(packet))
+			proofs[i].Data = h[:]
+		}
+	}
+	return proofs, nil
+}
+
+// QueryAcknowledgementProofs returns the proofs of the acknowledgement commitments at the same height.
+// `acknowledgements[i]` must be the acknowledgement of `packets[i]`.
+func (counterparty *Chain) QueryAcknowledgementProofs(chain *Chain, counterpartyClientID string, packets []channeltypes.Packet, acknowledgements [][]byte, height *big.Int) ([]*Proof, error) {
+	if len(packets) != len(acknowledgements) {
+		return nil, fmt.Errorf("length mismatch: packets=%v acknowledgements=%v", len(packets), len(acknowledgements))
+	}
+	var slots []string
+	for _, packet := range packets {
+		slots = append(slots, counterparty.ContractConfig.StoreLayout.PacketAcknowledgementCommitmentSlot(packet.DestinationPort, packet.DestinationChannel, packet.Sequence))
+	}
+	proofs, err := counterparty.QueryProofs(chain, counterpartyClientID, slots, height)
+	if err != nil {
+		return nil, err
+	}
+	switch chain.ClientType() {
+	case ibcclient.MockClient:
+		for i, ack := range acknowledgements {
+			h := sha256.Sum256(commitAcknowledgement(ack))
+			proofs[i].Data = h[:]
+		}
+	}
+	return proofs, nil
+}
+
+// QueryNonMembershipProof returns a proof of the absence of `storageKey`.
+// It returns an error if the slot exists or the light client doesn't provide storage proofs.
+func (chain *Chain) QueryNonMembershipProof(counterparty *Chain, counterpartyClientID string, storageKey string, height *big.Int) (*Proof, error) {
+	proof, err := chain.QueryProof(counterparty, counterpartyClientID, storageKey, height)
+	if err != nil {
+		return nil, err
+	} else if proof.Storage == nil {
+		return nil, fmt.Errorf("storage proof is not supported: clientType=%v", chain.ClientType())
+	} else if proof.Storage.Exists {
+		return nil, fmt.Errorf("slot exists: slot=%v value=%v", storageKey, proof.Storage.Value)
+	}
+	return proof, nil
 }
 
 func (counterparty *Chain) QueryClientProof(chain *Chain, counterpartyClientID string, height *big.Int) ([]byte, *Proof, error) {
@@ -876,7 +1112,7 @@ func (counterparty *Chain) QueryClientProof(chain *Chain, counterpartyClientID s
 	} else if !found {
 		return nil, nil, fmt.Errorf("client not found: %v", counterpartyClientID)
 	}
-	proof, err := counterparty.QueryProof(chain, counterpartyClientID, commitment.ClientStateCommitmentSlot(counterpartyClientID), height)
+	proof, err := counterparty.QueryProof(chain, counterpartyClientID, counterparty.ContractConfig.StoreLayout.ClientStateCommitmentSlot(counterpartyClientID), height)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -889,7 +1125,7 @@ func (counterparty *Chain) QueryClientProof(chain *Chain, counterpartyClientID s
 }
 
 func (counterparty *Chain) QueryConnectionProof(chain *Chain, counterpartyClientID string, counterpartyConnectionID string, height *big.Int) (*Proof, error) {
-	proof, err := counterparty.QueryProof(chain, counterpartyClientID, commitment.ConnectionStateCommitmentSlot(counterpartyConnectionID), height)
+	proof, err := counterparty.QueryProof(chain, counterpartyClientID, counterparty.ContractConfig.StoreLayout.ConnectionStateCommitmentSlot(counterpartyConnectionID), height)
 	if err != nil {
 		return nil, err
 	}
@@ -915,7 +1151,7 @@ func (counterparty *Chain) QueryConnectionProof(chain *Chain, counterpartyClient
 }
 
 func (counterparty *Chain) QueryChannelProof(chain *Chain, counterpartyClientID string, channel TestChannel, height *big.Int) (*Proof, error) {
-	proof, err := counterparty.QueryProof(chain, counterpartyClientID, commitment.ChannelStateCommitmentSlot(channel.PortID, channel.ID), height)
+	proof, err := counterparty.QueryProof(chain, counterpartyClientID, counterparty.ContractConfig.StoreLayout.ChannelStateCommitmentSlot(channel.PortID, channel.ID), height)
 	if err != nil {
 		return nil, err
 	}
@@ -945,7 +1181,7 @@ func (chain *Chain) LastHeader() *gethtypes.Header {
 }
 
 func (chain *Chain) WaitForReceiptAndGet(ctx context.Context, tx *gethtypes.Transaction) error {
-	rc, err := chain.Client().WaitForReceiptAndGet(ctx, tx)
+	rc, err := chain.txMgr.WaitMined(ctx, tx)
 	if err != nil {
 		return err
 	}
@@ -956,6 +1192,27 @@ func (chain *Chain) WaitForReceiptAndGet(ctx context.Context, tx *gethtypes.Tran
 	}
 }
 
+// SetKeyPool makes the messages sent to the IBCHandler use the accounts of the pool instead of RelayerKeyIndex.
+// If the pool is nil, RelayerKeyIndex is used.
+func (chain *Chain) SetKeyPool(pool *KeyPool) {
+	chain.keyPool = pool
+}
+
+func (chain *Chain) KeyPool() *KeyPool {
+	return chain.keyPool
+}
+
+// SendRelayerTx sends the transaction built by `send` from a relayer account and waits for it to be mined.
+// The account is taken from the key pool if it's set, otherwise RelayerKeyIndex is used.
+// In the simulation mode, the transaction is simulated instead of being sent. See SetSimulation.
+func (chain *Chain) SendRelayerTx(ctx context.Context, send func(opts *bind.TransactOpts) (*gethtypes.Transaction, error)) error {
+	if chain.keyPool != nil {
+		return chain.keyPool.Submit(ctx, send)
+	}
+	tx, err := send(chain.relayerTxOpts(ctx, RelayerKeyIndex))
+	return chain.waitOrSimulate(ctx, tx, err)
+}
+
 func (chain *Chain) WaitIfNoError(ctx context.Context) func(tx *gethtypes.Transaction, err error) error {
 	return func(tx *gethtypes.Transaction, err error) error {
 		if err != nil {
@@ -1012,23 +1269,8 @@ func (chain *Chain) NextTestChannel(conn *TestConnection, portID string) TestCha
 	}
 }
 
-func makeGenTxOpts(chainID *big.Int, prv *ecdsa.PrivateKey) func(ctx context.Context) *bind.TransactOpts {
-	signer := gethtypes.LatestSignerForChainID(chainID)
-	addr := gethcrypto.PubkeyToAddress(prv.PublicKey)
+func makeGenTxOpts(txMgr *client.TxManager, chainID *big.Int, signer wallet.Signer) func(ctx context.Context) *bind.TransactOpts {
 	return func(ctx context.Context) *bind.TransactOpts {
-		return &bind.TransactOpts{
-			From:     addr,
-			GasLimit: 6382056,
-			Signer: func(address common.Address, tx *gethtypes.Transaction) (*gethtypes.Transaction, error) {
-				if address != addr {
-					return nil, errors.New("not authorized to sign this account")
-				}
-				signature, err := gethcrypto.Sign(signer.Hash(tx).Bytes(), prv)
-				if err != nil {
-					return nil, err
-				}
-				return tx.WithSignature(signer, signature)
-			},
-		}
+		return txMgr.TransactOpts(ctx, signer.Address(), wallet.SignerFn(ctx, signer, chainID))
 	}
 }
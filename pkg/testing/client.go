@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"os"
 
 	"github.com/ethereum/go-ethereum/common"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/chains"
 	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/client"
 	ibcclient "github.com/hyperledger-labs/yui-ibc-solidity/pkg/ibc/core/client"
@@ -15,10 +17,31 @@ import (
 type LightClient struct {
 	client     *client.ETHClient
 	clientType string
+	// consensus is the consensus type of the chain. If it is empty, it is detected from each header.
+	consensus chains.ConsensusType
 }
 
+// NewLightClient creates a light client. The consensus type is taken from the environ variable TEST_CONSENSUS_TYPE("ibft2", "qbft" or "clique") if it's set,
+// otherwise it is detected from each header by chains.DetectConsensusType.
 func NewLightClient(cl *client.ETHClient, clientType string) *LightClient {
-	return &LightClient{client: cl, clientType: clientType}
+	var consensus chains.ConsensusType
+	if s := os.Getenv("TEST_CONSENSUS_TYPE"); s != "" {
+		var err error
+		if consensus, err = chains.ParseConsensusType(s); err != nil {
+			panic(fmt.Sprintf("invalid TEST_CONSENSUS_TYPE: %v", err))
+		}
+	}
+	return &LightClient{client: cl, clientType: clientType, consensus: consensus}
+}
+
+// NewLightClientWithConsensus creates a light client for a chain of the consensus type(IBFT2 or QBFT).
+func NewLightClientWithConsensus(cl *client.ETHClient, clientType string, consensus chains.ConsensusType) *LightClient {
+	return &LightClient{client: cl, clientType: clientType, consensus: consensus}
+}
+
+// Consensus returns the consensus type of the chain. It's empty if the consensus type is detected from each header.
+func (lc LightClient) Consensus() chains.ConsensusType {
+	return lc.consensus
 }
 
 type LightClientState interface {
@@ -30,19 +53,26 @@ func (lc LightClient) ClientType() string {
 	return lc.clientType
 }
 
+// GetState returns the state at the block of the number. If the number is nil, it returns the state at the latest block.
 func (lc LightClient) GetState(ctx context.Context, address common.Address, storageKeys [][]byte, bn *big.Int) (LightClientState, error) {
+	return lc.GetStateAt(ctx, address, storageKeys, client.BlockRef(bn))
+}
+
+// GetStateAt returns the state at the block referred by a number, a tag("latest", "safe" or "finalized") or a hash.
+// A tag is resolved to a height first so that the header and the proof refer to the same block.
+func (lc LightClient) GetStateAt(ctx context.Context, address common.Address, storageKeys [][]byte, block rpc.BlockNumberOrHash) (LightClientState, error) {
 	switch lc.clientType {
 	case ibcclient.BesuIBFT2Client:
-		return lc.GetIBFT2State(ctx, address, storageKeys, bn)
+		return lc.GetIBFT2State(ctx, address, storageKeys, block)
 	case ibcclient.MockClient:
-		return lc.GetMockContractState(ctx, address, storageKeys, bn)
+		return lc.GetMockContractState(ctx, address, storageKeys, block)
 	default:
 		panic(fmt.Sprintf("unknown client type '%v'", lc.clientType))
 	}
 }
 
-func (lc LightClient) GetMockContractState(ctx context.Context, address common.Address, storageKeys [][]byte, bn *big.Int) (LightClientState, error) {
-	block, err := lc.client.BlockByNumber(ctx, bn)
+func (lc LightClient) GetMockContractState(ctx context.Context, address common.Address, storageKeys [][]byte, block rpc.BlockNumberOrHash) (LightClientState, error) {
+	header, err := lc.client.HeaderByRef(ctx, block)
 	if err != nil {
 		return nil, err
 	}
@@ -50,27 +80,56 @@ func (lc LightClient) GetMockContractState(ctx context.Context, address common.A
 	proof := &client.StateProof{
 		StorageProofRLP: make([][]byte, len(storageKeys)),
 	}
-	return ETHState{header: block.Header(), StateProof: proof}, nil
+	return ETHState{header: header, StateProof: proof}, nil
 }
 
-func (lc LightClient) GetIBFT2State(ctx context.Context, address common.Address, storageKeys [][]byte, bn *big.Int) (LightClientState, error) {
-	var state IBFT2State
-	block, err := lc.client.BlockByNumber(ctx, bn)
+func (lc LightClient) GetIBFT2State(ctx context.Context, address common.Address, storageKeys [][]byte, block rpc.BlockNumberOrHash) (LightClientState, error) {
+	if number, ok := block.Number(); ok && number < 0 {
+		// resolve the tag to a height so that the header and the proof are queried from the same endpoint
+		header, err := lc.client.HeaderByRef(ctx, block)
+		if err != nil {
+			return nil, err
+		}
+		block = client.BlockRef(header.Number)
+	}
+	if number, ok := block.Number(); ok {
+		ctx = client.WithPinnedHeight(ctx, big.NewInt(number.Int64()))
+	}
+	state, err := lc.GetIBFT2Header(ctx, block)
 	if err != nil {
 		return nil, err
 	}
-	proof, err := lc.client.GetProof(address, storageKeys, block.Number())
-	if err != nil {
+	if state.StateProof, err = lc.client.GetProofBatch(ctx, address, storageKeys, block); err != nil {
 		return nil, err
 	}
-	state.StateProof = proof
-	state.ParsedHeader, err = chains.ParseHeader(block.Header())
+	return state, nil
+}
+
+// GetIBFT2Header returns the state at the block without the state proof, e.g. to verify the header before fetching the proof.
+func (lc LightClient) GetIBFT2Header(ctx context.Context, block rpc.BlockNumberOrHash) (IBFT2State, error) {
+	var state IBFT2State
+	header, hash, err := lc.client.HeaderAndHashByRef(ctx, block)
 	if err != nil {
-		return nil, err
+		return state, err
+	}
+	consensus := lc.consensus
+	if consensus == "" {
+		consensus, err = chains.DetectConsensusType(header)
+		if err != nil {
+			return state, err
+		}
+	}
+	state.ParsedHeader, err = chains.ParseHeaderByConsensus(header, consensus)
+	if err != nil {
+		return state, err
+	}
+	// check the header encoding before the seals so that an unsupported header shape isn't reported as insufficient voting
+	if err := state.ParsedHeader.VerifyBlockHash(hash); err != nil {
+		return state, err
 	}
 	state.CommitSeals, err = state.ParsedHeader.ValidateAndGetCommitSeals()
 	if err != nil {
-		return nil, err
+		return state, err
 	}
 	return state, nil
 }
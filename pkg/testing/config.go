@@ -6,6 +6,8 @@ import (
 	"os"
 
 	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/ibc/core/commitment"
 )
 
 type ContractConfig struct {
@@ -14,6 +16,9 @@ type ContractConfig struct {
 	ICS20BankAddress               common.Address
 	IBCCommitmentTestHelperAddress common.Address
 	ERC20TokenAddress              common.Address
+
+	// StoreLayout determines the slots of the commitments in the IBC store of IBCHandler
+	StoreLayout commitment.StoreLayout
 }
 
 func (cc *ContractConfig) Validate() error {
@@ -48,7 +53,7 @@ func buildContractConfigFromBroadcastLog(path string) (*ContractConfig, error) {
 	if err != nil {
 		return nil, err
 	}
-	var cc ContractConfig
+	cc := ContractConfig{StoreLayout: commitment.DefaultStoreLayout}
 
 	var log BroadcastLog
 	if err := json.Unmarshal(bz, &log); err != nil {
@@ -0,0 +1,183 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// poolKeyIndexOffset is the key index of the first pool account
+const poolKeyIndexOffset = RelayerKeyIndex + 1
+
+// KeyPool hands out relayer accounts to the messages sent to the IBCHandler, so that messages from different goroutines are sent by different senders.
+// Each account has at most one transaction in flight, and an account whose balance is low is funded by the funder before it is handed out.
+// The funder is never a pool account, so funding doesn't compete with the messages for the nonces of the accounts.
+type KeyPool struct {
+	chain  *Chain
+	option keyPoolOption
+
+	accounts []*poolAccount
+	free     chan *poolAccount
+	// fundMu serializes the transfers from the funder
+	fundMu sync.Mutex
+}
+
+type poolAccount struct {
+	index   uint32
+	address common.Address
+
+	mu sync.Mutex
+	// inFlight is the transaction sent but not mined yet
+	inFlight *gethtypes.Transaction
+}
+
+type KeyPoolOption func(*keyPoolOption)
+
+type keyPoolOption struct {
+	// funder is the key index of the account that funds the pool accounts
+	funder uint32
+	// minBalance is the balance below which an account is funded. If it's nil, the accounts are never funded.
+	minBalance *big.Int
+	// topUp is the amount transferred to an account whose balance is low
+	topUp *big.Int
+}
+
+func DefaultKeyPoolOption() *keyPoolOption {
+	return &keyPoolOption{
+		funder: RelayerKeyIndex,
+	}
+}
+
+// WithFunder sets the key index of the account that funds the pool accounts. The deployer(RelayerKeyIndex) is used by default.
+// The index must not be in the range of the pool accounts.
+func WithFunder(index uint32) KeyPoolOption {
+	return func(opt *keyPoolOption) {
+		opt.funder = index
+	}
+}
+
+// WithRebalance enables funding an account with `topUp` when its balance is less than `minBalance`
+func WithRebalance(minBalance, topUp *big.Int) KeyPoolOption {
+	return func(opt *keyPoolOption) {
+		opt.minBalance = minBalance
+		opt.topUp = topUp
+	}
+}
+
+// NewKeyPool returns a KeyPool of the accounts of the key indexes `[RelayerKeyIndex+1, RelayerKeyIndex+1+size)` of the chain's keyring.
+// RelayerKeyIndex is excluded because it's the default funder and the sender of the messages sent without the pool.
+// Call Chain.SetKeyPool to use it for the messages sent by the chain.
+func NewKeyPool(chain *Chain, size uint32, opts ...KeyPoolOption) (*KeyPool, error) {
+	if size == 0 {
+		return nil, fmt.Errorf("size must be greater than zero")
+	}
+	opt := DefaultKeyPoolOption()
+	for _, o := range opts {
+		o(opt)
+	}
+	if opt.minBalance != nil && (opt.topUp == nil || opt.topUp.Sign() <= 0) {
+		return nil, fmt.Errorf("top-up amount must be positive: %v", opt.topUp)
+	}
+	if opt.funder >= poolKeyIndexOffset && opt.funder-poolKeyIndexOffset < size {
+		return nil, fmt.Errorf("funder must not be a pool account: funder=%v pool=[%v, %v)", opt.funder, poolKeyIndexOffset, poolKeyIndexOffset+size)
+	}
+	pool := &KeyPool{
+		chain:  chain,
+		option: *opt,
+		free:   make(chan *poolAccount, size),
+	}
+	for i := uint32(0); i < size; i++ {
+		signer, err := chain.keyring.Signer(poolKeyIndexOffset + i)
+		if err != nil {
+			return nil, err
+		}
+		acc := &poolAccount{
+			index:   poolKeyIndexOffset + i,
+			address: signer.Address(),
+		}
+		pool.accounts = append(pool.accounts, acc)
+		pool.free <- acc
+	}
+	return pool, nil
+}
+
+// Accounts returns the addresses of the pool accounts
+func (p *KeyPool) Accounts() []common.Address {
+	var addrs []common.Address
+	for _, acc := range p.accounts {
+		addrs = append(addrs, acc.address)
+	}
+	return addrs
+}
+
+// InFlightNonces returns the nonce of the transaction in flight for each account that has sent a transaction not mined yet
+func (p *KeyPool) InFlightNonces() map[common.Address]uint64 {
+	res := make(map[common.Address]uint64)
+	for _, acc := range p.accounts {
+		acc.mu.Lock()
+		if acc.inFlight != nil {
+			res[acc.address] = acc.inFlight.Nonce()
+		}
+		acc.mu.Unlock()
+	}
+	return res
+}
+
+// Submit waits for a free account, sends the transaction built by `send` from the account and waits for it to be mined.
+// The account is returned to the pool after the transaction is mined or fails.
+func (p *KeyPool) Submit(ctx context.Context, send func(opts *bind.TransactOpts) (*gethtypes.Transaction, error)) error {
+	var acc *poolAccount
+	select {
+	case acc = <-p.free:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { p.free <- acc }()
+
+	if err := p.rebalance(ctx, acc); err != nil {
+		return err
+	}
+	tx, err := send(p.chain.relayerTxOpts(ctx, acc.index))
+	if err == nil && !p.chain.simulation {
+		acc.setInFlight(tx)
+		defer acc.setInFlight(nil)
+	}
+	return p.chain.waitOrSimulate(ctx, tx, err)
+}
+
+// rebalance transfers the top-up amount from the funder if the balance of the account is low
+func (p *KeyPool) rebalance(ctx context.Context, acc *poolAccount) error {
+	if p.option.minBalance == nil || p.chain.simulation {
+		return nil
+	}
+	balance, err := p.chain.client.BalanceAt(ctx, acc.address, nil)
+	if err != nil {
+		return err
+	} else if balance.Cmp(p.option.minBalance) >= 0 {
+		return nil
+	}
+	p.fundMu.Lock()
+	defer p.fundMu.Unlock()
+	opts := p.chain.TxOpts(ctx, p.option.funder)
+	opts.Value = p.option.topUp
+	// the gas limit of a plain transfer is given because bind rejects estimating the gas for an account without code
+	opts.GasLimit = params.TxGas
+	tx, err := bind.NewBoundContract(acc.address, abi.ABI{}, nil, p.chain.txMgr, nil).Transfer(opts)
+	if err != nil {
+		return fmt.Errorf("failed to fund the relayer account: address=%v balance=%v err=%v", acc.address, balance, err)
+	}
+	return p.chain.WaitForReceiptAndGet(ctx, tx)
+}
+
+func (acc *poolAccount) setInFlight(tx *gethtypes.Transaction) {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	acc.inFlight = tx
+}
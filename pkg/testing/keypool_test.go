@@ -0,0 +1,247 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/client"
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/wallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyPool(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	keyring := newTestKeyring(t, 3)
+	funder, acc1, acc2 := keyring[0].Address(), keyring[1].Address(), keyring[2].Address()
+	sink := common.Address{0xff}
+
+	service := &testEthService{
+		chainID: 1337,
+		balances: map[common.Address]*big.Int{
+			funder: big.NewInt(100),
+			acc1:   big.NewInt(10),
+		},
+		hold: map[common.Address]bool{sink: true},
+	}
+	chain := newTestChain(t, service, keyring)
+
+	// 1. The funder must not be a pool account
+	_, err := NewKeyPool(chain, 2, WithFunder(2))
+	require.Error(t, err)
+
+	pool, err := NewKeyPool(chain, 2, WithRebalance(big.NewInt(5), big.NewInt(20)))
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{acc1, acc2}, pool.Accounts())
+
+	transfer := func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		opts.GasLimit = params.TxGas
+		return bind.NewBoundContract(sink, abi.ABI{}, nil, chain.txMgr, nil).Transfer(opts)
+	}
+
+	// 2. Concurrent submissions are sent by different accounts and the low balance account is funded
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			errs <- pool.Submit(ctx, transfer)
+		}()
+	}
+	require.Eventually(t, func() bool {
+		return len(pool.InFlightNonces()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, map[common.Address]uint64{acc1: 0, acc2: 0}, pool.InFlightNonces())
+	require.Equal(t, big.NewInt(80), service.balance(funder))
+	require.Equal(t, big.NewInt(20), service.balance(acc2))
+
+	service.release(sink)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, <-errs)
+	}
+	require.Empty(t, pool.InFlightNonces())
+
+	// 3. The account is returned to the pool if sending fails
+	for i := 0; i < 3; i++ {
+		require.Error(t, pool.Submit(ctx, func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+			return nil, errors.New("failed to build")
+		}))
+	}
+	require.NoError(t, pool.Submit(ctx, transfer))
+	require.Empty(t, pool.InFlightNonces())
+	// the accounts have enough balance, so the funder sent only one transaction
+	require.Equal(t, big.NewInt(80), service.balance(funder))
+}
+
+func newTestKeyring(t *testing.T, n int) wallet.StaticKeyring {
+	var keyring wallet.StaticKeyring
+	for i := 0; i < n; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		signer, err := wallet.NewRawKeySigner(hexutil.Encode(crypto.FromECDSA(key)))
+		require.NoError(t, err)
+		keyring = append(keyring, signer)
+	}
+	return keyring
+}
+
+// newTestChain returns a Chain connected to the service. The server is stopped at the end of the test.
+func newTestChain(t *testing.T, service *testEthService, keyring wallet.Keyring) *Chain {
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	t.Cleanup(server.Stop)
+	ethClient := client.NewETHClientFromRPCClient(rpc.DialInProc(server))
+	return &Chain{
+		t:       t,
+		chainID: service.chainID,
+		client:  ethClient,
+		txMgr:   client.NewTxManager(ethClient, big.NewInt(service.chainID)),
+		keyring: keyring,
+		signers: make(map[uint32]wallet.Signer),
+	}
+}
+
+// testEthService is a minimal eth namespace that executes plain value transfers.
+// The receipts of the transactions to the accounts in `hold` are not returned until they are released.
+type testEthService struct {
+	mu      sync.Mutex
+	chainID int64
+	number  int64
+	feed    event.Feed
+
+	balances map[common.Address]*big.Int
+	nonces   map[common.Address]uint64
+	hold     map[common.Address]bool
+	sent     map[common.Hash]*gethtypes.Transaction
+}
+
+func (s *testEthService) balance(address common.Address) *big.Int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if balance, ok := s.balances[address]; ok {
+		return new(big.Int).Set(balance)
+	}
+	return new(big.Int)
+}
+
+// release returns the receipts of the transactions to `to` from the next block
+func (s *testEthService) release(to common.Address) {
+	s.mu.Lock()
+	delete(s.hold, to)
+	s.number++
+	header := s.header()
+	s.mu.Unlock()
+	s.feed.Send(header)
+}
+
+func (s *testEthService) header() *gethtypes.Header {
+	return &gethtypes.Header{Number: big.NewInt(s.number), Difficulty: big.NewInt(0)}
+}
+
+func (s *testEthService) ChainId(ctx context.Context) (*hexutil.Big, error) {
+	return (*hexutil.Big)(big.NewInt(s.chainID)), nil
+}
+
+func (s *testEthService) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool) (*gethtypes.Header, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.header(), nil
+}
+
+func (s *testEthService) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	return (*hexutil.Big)(big.NewInt(0)), nil
+}
+
+func (s *testEthService) GetBalance(ctx context.Context, address common.Address, block string) (*hexutil.Big, error) {
+	return (*hexutil.Big)(s.balance(address)), nil
+}
+
+func (s *testEthService) GetTransactionCount(ctx context.Context, address common.Address, block string) (hexutil.Uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return hexutil.Uint64(s.nonces[address]), nil
+}
+
+func (s *testEthService) SendRawTransaction(ctx context.Context, input hexutil.Bytes) (common.Hash, error) {
+	var tx gethtypes.Transaction
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	from, err := gethtypes.Sender(gethtypes.LatestSignerForChainID(big.NewInt(s.chainID)), &tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.nonces == nil {
+		s.nonces = make(map[common.Address]uint64)
+		s.sent = make(map[common.Hash]*gethtypes.Transaction)
+	}
+	if tx.Nonce() != s.nonces[from] {
+		return common.Hash{}, errors.New("invalid nonce")
+	}
+	balance := s.balances[from]
+	if balance == nil || balance.Cmp(tx.Value()) < 0 {
+		return common.Hash{}, errors.New("insufficient funds")
+	}
+	s.balances[from] = new(big.Int).Sub(balance, tx.Value())
+	if to := s.balances[*tx.To()]; to != nil {
+		s.balances[*tx.To()] = new(big.Int).Add(to, tx.Value())
+	} else {
+		s.balances[*tx.To()] = new(big.Int).Set(tx.Value())
+	}
+	s.nonces[from]++
+	s.sent[tx.Hash()] = &tx
+	return tx.Hash(), nil
+}
+
+func (s *testEthService) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*gethtypes.Receipt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, ok := s.sent[hash]
+	if !ok || s.hold[*tx.To()] {
+		return nil, nil
+	}
+	return &gethtypes.Receipt{
+		Status:      gethtypes.ReceiptStatusSuccessful,
+		TxHash:      hash,
+		Logs:        []*gethtypes.Log{},
+		BlockNumber: big.NewInt(s.number),
+	}, nil
+}
+
+func (s *testEthService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	heads := make(chan *gethtypes.Header)
+	feedSub := s.feed.Subscribe(heads)
+	go func() {
+		defer feedSub.Unsubscribe()
+		for {
+			select {
+			case header := <-heads:
+				if err := notifier.Notify(sub.ID, header); err != nil {
+					return
+				}
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
@@ -0,0 +1,48 @@
+package testing
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/wallet"
+)
+
+// KeyringFromEnv returns the keyring configured by the environ variables. They are checked in the following order:
+//   - TEST_MNEMONIC: the mnemonic to derive the key of the index with the path "m/44'/60'/0'/0/{index}".
+//     TEST_MNEMONIC_PASSPHRASE and TEST_HD_PATH_TEMPLATE override the BIP39 passphrase and the path template.
+//   - TEST_PRIVATE_KEYS: the comma separated hex encoded private keys
+//   - TEST_KEYSTORE_FILES: the comma separated paths of geth keystore files decrypted with TEST_KEYSTORE_PASSPHRASE
+func KeyringFromEnv() (wallet.Keyring, error) {
+	if mnemonic := os.Getenv("TEST_MNEMONIC"); mnemonic != "" {
+		template := os.Getenv("TEST_HD_PATH_TEMPLATE")
+		if template == "" {
+			template = wallet.DefaultHDPathTemplate
+		}
+		return wallet.NewMnemonicKeyringWithPath(mnemonic, os.Getenv("TEST_MNEMONIC_PASSPHRASE"), template)
+	}
+	if keys := os.Getenv("TEST_PRIVATE_KEYS"); keys != "" {
+		var keyring wallet.StaticKeyring
+		for i, key := range strings.Split(keys, ",") {
+			signer, err := wallet.NewRawKeySigner(key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid private key in TEST_PRIVATE_KEYS: index=%v err=%v", i, err)
+			}
+			keyring = append(keyring, signer)
+		}
+		return keyring, nil
+	}
+	if files := os.Getenv("TEST_KEYSTORE_FILES"); files != "" {
+		passphrase := os.Getenv("TEST_KEYSTORE_PASSPHRASE")
+		var keyring wallet.StaticKeyring
+		for _, path := range strings.Split(files, ",") {
+			signer, err := wallet.NewKeystoreFileSigner(strings.TrimSpace(path), passphrase)
+			if err != nil {
+				return nil, err
+			}
+			keyring = append(keyring, signer)
+		}
+		return keyring, nil
+	}
+	return nil, fmt.Errorf("environ variable 'TEST_MNEMONIC', 'TEST_PRIVATE_KEYS' or 'TEST_KEYSTORE_FILES' must be set")
+}
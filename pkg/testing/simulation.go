@@ -0,0 +1,121 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/client"
+)
+
+const simulationGasLimit uint64 = 1
+
+// SimulationResult is the result of a message to the IBCHandler executed with `eth_call`. Nothing was broadcast for the message.
+type SimulationResult struct {
+	Tx *gethtypes.Transaction
+	// Gas is the estimated gas if the execution succeeded
+	Gas uint64
+	// Revert is the decoded revert reason if the execution reverted
+	Revert *client.RevertError
+}
+
+// SetSimulation enables or disables the simulation mode.
+// In the simulation mode, each message to the IBCHandler is executed with `eth_call` from the sender at the pending block instead of being broadcast.
+// If the execution reverts, the revert error is returned(see client.RevertError). Otherwise, no error is returned.
+// The results including the estimated gas are collected by the recorder of the context(see WithSimulationRecorder).
+// The other transactions, e.g. ERC20 and ICS20 calls with TxOpts, are sent as usual.
+// NOTE: a simulated message doesn't change the state, so the helpers returning a generated identifier(e.g. CreateIBFT2Client) return the last one generated on the chain.
+func (chain *Chain) SetSimulation(enabled bool) {
+	chain.simulation = enabled
+}
+
+func (chain *Chain) Simulation() bool {
+	return chain.simulation
+}
+
+// SimulateRelayerTx executes the message built by `send` with `eth_call` from RelayerKeyIndex regardless of the simulation mode.
+// A revert is reported in the result, and the error is returned only if the message can't be simulated.
+func (chain *Chain) SimulateRelayerTx(ctx context.Context, send func(opts *bind.TransactOpts) (*gethtypes.Transaction, error)) (*SimulationResult, error) {
+	tx, err := send(simulationTxOpts(chain.TxOpts(ctx, RelayerKeyIndex)))
+	if err != nil {
+		return nil, err
+	}
+	return chain.simulate(ctx, tx)
+}
+
+// SimulationRecorder collects the results of the messages simulated with a context returned by WithSimulationRecorder
+type SimulationRecorder struct {
+	mu      sync.Mutex
+	results []*SimulationResult
+}
+
+type simulationRecorderKey struct{}
+
+// WithSimulationRecorder returns a context that records the results of the messages simulated in the simulation mode with it
+func WithSimulationRecorder(ctx context.Context) (context.Context, *SimulationRecorder) {
+	recorder := &SimulationRecorder{}
+	return context.WithValue(ctx, simulationRecorderKey{}, recorder), recorder
+}
+
+// Results returns the results in the order the messages were simulated
+func (r *SimulationRecorder) Results() []*SimulationResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*SimulationResult{}, r.results...)
+}
+
+func (r *SimulationRecorder) record(result *SimulationResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, result)
+}
+
+// relayerTxOpts returns TxOpts for a message to the IBCHandler
+func (chain *Chain) relayerTxOpts(ctx context.Context, index uint32) *bind.TransactOpts {
+	opts := chain.TxOpts(ctx, index)
+	if chain.simulation {
+		return simulationTxOpts(opts)
+	}
+	return opts
+}
+
+// simulationTxOpts makes bind only sign the transaction. The nonce and the gas limit are placeholders to avoid reserving a nonce and estimating the gas in bind.
+func simulationTxOpts(opts *bind.TransactOpts) *bind.TransactOpts {
+	opts.NoSend = true
+	opts.Nonce = big.NewInt(0)
+	opts.GasLimit = simulationGasLimit
+	return opts
+}
+
+// waitOrSimulate is WaitIfNoError for a message to the IBCHandler. In the simulation mode, the message is simulated instead of waiting for it to be mined.
+func (chain *Chain) waitOrSimulate(ctx context.Context, tx *gethtypes.Transaction, err error) error {
+	if err != nil || !chain.simulation {
+		return chain.WaitIfNoError(ctx)(tx, err)
+	}
+	result, err := chain.simulate(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if recorder, ok := ctx.Value(simulationRecorderKey{}).(*SimulationRecorder); ok {
+		recorder.record(result)
+	}
+	if result.Revert != nil {
+		return result.Revert
+	}
+	return nil
+}
+
+func (chain *Chain) simulate(ctx context.Context, tx *gethtypes.Transaction) (*SimulationResult, error) {
+	signer := gethtypes.LatestSignerForChainID(big.NewInt(chain.chainID))
+	gas, err := chain.client.SimulateSignedTransaction(ctx, signer, tx)
+	var revert *client.RevertError
+	if errors.As(err, &revert) {
+		return &SimulationResult{Tx: tx, Revert: revert}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &SimulationResult{Tx: tx, Gas: gas}, nil
+}
@@ -0,0 +1,102 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/hyperledger-labs/yui-ibc-solidity/pkg/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	keyring := newTestKeyring(t, 1)
+	service := &testEthService{
+		chainID:  1337,
+		balances: map[common.Address]*big.Int{keyring[0].Address(): big.NewInt(100)},
+	}
+	chain := newTestChain(t, service, keyring)
+	chain.SetSimulation(true)
+	to := common.Address{0xff}
+	message := func(data []byte) func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+		return func(opts *bind.TransactOpts) (*gethtypes.Transaction, error) {
+			return bind.NewBoundContract(to, abi.ABI{}, nil, chain.txMgr, nil).RawTransact(opts, data)
+		}
+	}
+	revertData := append(hexutil.MustDecode("0x08c379a0"), hexutil.MustDecode(
+		"0x0000000000000000000000000000000000000000000000000000000000000020"+
+			"0000000000000000000000000000000000000000000000000000000000000004"+
+			"626f6f6d00000000000000000000000000000000000000000000000000000000",
+	)...)
+
+	// 1. The results of the messages are recorded to the recorder of the context
+	recordCtx, recorder := WithSimulationRecorder(ctx)
+	require.NoError(t, chain.SendRelayerTx(recordCtx, message([]byte{0x01})))
+	err := chain.SendRelayerTx(recordCtx, message(revertData))
+	var revertErr *client.RevertError
+	require.True(t, errors.As(err, &revertErr), err)
+	results := recorder.Results()
+	require.Len(t, results, 2)
+	require.Equal(t, uint64(50000), results[0].Gas)
+	require.Nil(t, results[0].Revert)
+	require.Equal(t, revertErr, results[1].Revert)
+
+	// 2. A message is simulated directly regardless of the mode
+	chain.SetSimulation(false)
+	result, err := chain.SimulateRelayerTx(ctx, message(revertData))
+	require.NoError(t, err)
+	require.NotNil(t, result.Revert)
+
+	// 3. The other transactions are sent even in the simulation mode
+	chain.SetSimulation(true)
+	opts := chain.TxOpts(ctx, 0)
+	opts.Value = big.NewInt(1)
+	opts.GasLimit = params.TxGas
+	require.NoError(t, chain.WaitIfNoError(ctx)(bind.NewBoundContract(to, abi.ABI{}, nil, chain.txMgr, nil).Transfer(opts)))
+	require.Equal(t, big.NewInt(1), service.balance(to))
+	require.Len(t, service.sent, 1)
+}
+
+// Call reverts with the input as the revert data if the input is longer than 4 bytes
+func (s *testEthService) Call(ctx context.Context, args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	input, _ := args["data"].(string)
+	data, err := hexutil.Decode(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 4 {
+		return nil, &testRevertError{data: data}
+	}
+	return hexutil.Bytes{}, nil
+}
+
+func (s *testEthService) EstimateGas(ctx context.Context, args map[string]interface{}) (hexutil.Uint64, error) {
+	return 50000, nil
+}
+
+type testRevertError struct {
+	data []byte
+}
+
+func (e *testRevertError) Error() string {
+	return "execution reverted"
+}
+
+func (e *testRevertError) ErrorCode() int {
+	return 3
+}
+
+func (e *testRevertError) ErrorData() interface{} {
+	return hexutil.Encode(e.data)
+}
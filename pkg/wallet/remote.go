@@ -0,0 +1,215 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// web3Signer is a Signer that requests signatures to Web3Signer through `POST /api/v1/eth1/sign/{identifier}`
+type web3Signer struct {
+	endpoint   string
+	identifier string
+	address    common.Address
+	client     *http.Client
+}
+
+var _ Signer = (*web3Signer)(nil)
+
+// NewWeb3Signer returns a Signer of the account managed by Web3Signer at the endpoint(e.g. "http://localhost:9000").
+// The identifier is the key identifier of Web3Signer and the address is used to check the returned signature.
+func NewWeb3Signer(endpoint, identifier string, address common.Address) Signer {
+	return &web3Signer{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		identifier: identifier,
+		address:    address,
+		client:     http.DefaultClient,
+	}
+}
+
+func (s *web3Signer) Address() common.Address {
+	return s.address
+}
+
+// SignTx sends the signing payload of the transaction. Web3Signer returns the signature of the keccak256 hash of the payload.
+func (s *web3Signer) SignTx(ctx context.Context, tx *gethtypes.Transaction, chainID *big.Int) (*gethtypes.Transaction, error) {
+	payload, err := signingPayload(tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(map[string]string{"data": hexutil.Encode(payload)})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/api/v1/eth1/sign/%s", s.endpoint, s.identifier), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	bz, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	} else if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("web3signer returned an error: status=%v body=%s", res.StatusCode, bz)
+	}
+	sig, err := hexutil.Decode(strings.TrimSpace(string(bz)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature from web3signer: %v", err)
+	} else if len(sig) != crypto.SignatureLength {
+		return nil, fmt.Errorf("invalid signature length from web3signer: %v", len(sig))
+	}
+	// Web3Signer returns the recovery id as 27 or 28
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	signed, err := tx.WithSignature(gethtypes.LatestSignerForChainID(chainID), sig)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSender(signed, chainID, s.address); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
+
+// clefSigner is a Signer that requests signatures to Clef through `account_signTransaction`
+type clefSigner struct {
+	client  *rpc.Client
+	address common.Address
+}
+
+var _ Signer = (*clefSigner)(nil)
+
+// NewClefSigner returns a Signer of the account managed by Clef at the endpoint(e.g. "http://localhost:8550")
+func NewClefSigner(ctx context.Context, endpoint string, address common.Address) (Signer, error) {
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return NewClefSignerFromRPCClient(client, address), nil
+}
+
+// NewClefSignerFromRPCClient returns a Signer that calls Clef with the client
+func NewClefSignerFromRPCClient(client *rpc.Client, address common.Address) Signer {
+	return &clefSigner{client: client, address: address}
+}
+
+func (s *clefSigner) Address() common.Address {
+	return s.address
+}
+
+// clefTxArgs is the transaction argument of `account_signTransaction`
+type clefTxArgs struct {
+	From                 common.MixedcaseAddress  `json:"from"`
+	To                   *common.MixedcaseAddress `json:"to"`
+	Gas                  hexutil.Uint64           `json:"gas"`
+	GasPrice             *hexutil.Big             `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big             `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big             `json:"maxPriorityFeePerGas,omitempty"`
+	Value                hexutil.Big              `json:"value"`
+	Nonce                hexutil.Uint64           `json:"nonce"`
+	Data                 *hexutil.Bytes           `json:"data"`
+	AccessList           *gethtypes.AccessList    `json:"accessList,omitempty"`
+	ChainID              *hexutil.Big             `json:"chainId,omitempty"`
+}
+
+type clefSignResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+// SignTx sends the transaction to Clef and checks that the returned transaction is the same one signed by the account
+func (s *clefSigner) SignTx(ctx context.Context, tx *gethtypes.Transaction, chainID *big.Int) (*gethtypes.Transaction, error) {
+	data := hexutil.Bytes(tx.Data())
+	args := clefTxArgs{
+		From:    common.NewMixedcaseAddress(s.address),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    &data,
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if to := tx.To(); to != nil {
+		addr := common.NewMixedcaseAddress(*to)
+		args.To = &addr
+	}
+	switch tx.Type() {
+	case gethtypes.LegacyTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case gethtypes.AccessListTxType:
+		accessList := tx.AccessList()
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+		args.AccessList = &accessList
+	case gethtypes.DynamicFeeTxType:
+		accessList := tx.AccessList()
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+		args.AccessList = &accessList
+	default:
+		return nil, fmt.Errorf("unsupported transaction type: %v", tx.Type())
+	}
+	var res clefSignResult
+	if err := s.client.CallContext(ctx, &res, "account_signTransaction", args); err != nil {
+		return nil, err
+	}
+	var signed gethtypes.Transaction
+	if err := signed.UnmarshalBinary(res.Raw); err != nil {
+		return nil, fmt.Errorf("invalid transaction from clef: %v", err)
+	}
+	signer := gethtypes.LatestSignerForChainID(chainID)
+	if signer.Hash(&signed) != signer.Hash(tx) {
+		return nil, fmt.Errorf("clef signed a different transaction: expected=%v actual=%v", signer.Hash(tx), signer.Hash(&signed))
+	}
+	if err := checkSender(&signed, chainID, s.address); err != nil {
+		return nil, err
+	}
+	return &signed, nil
+}
+
+// signingPayload returns the payload whose keccak256 hash is signed for the transaction
+func signingPayload(tx *gethtypes.Transaction, chainID *big.Int) ([]byte, error) {
+	switch tx.Type() {
+	case gethtypes.LegacyTxType:
+		return rlp.EncodeToBytes([]interface{}{
+			tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), chainID, uint(0), uint(0),
+		})
+	case gethtypes.AccessListTxType:
+		bz, err := rlp.EncodeToBytes([]interface{}{
+			chainID, tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), tx.AccessList(),
+		})
+		return append([]byte{tx.Type()}, bz...), err
+	case gethtypes.DynamicFeeTxType:
+		bz, err := rlp.EncodeToBytes([]interface{}{
+			chainID, tx.Nonce(), tx.GasTipCap(), tx.GasFeeCap(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), tx.AccessList(),
+		})
+		return append([]byte{tx.Type()}, bz...), err
+	default:
+		return nil, fmt.Errorf("unsupported transaction type: %v", tx.Type())
+	}
+}
+
+func checkSender(tx *gethtypes.Transaction, chainID *big.Int, expected common.Address) error {
+	sender, err := gethtypes.Sender(gethtypes.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		return err
+	} else if sender != expected {
+		return fmt.Errorf("unexpected signer: expected=%v actual=%v", expected, sender)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteSigners(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	chainID := big.NewInt(2018)
+
+	web3signer := httptest.NewServer(testWeb3SignerHandler(map[string]*ecdsa.PrivateKey{"key1": key, "other": other}))
+	defer web3signer.Close()
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("account", &testClefService{keys: map[common.Address]*ecdsa.PrivateKey{addr: key}}))
+	defer server.Stop()
+	clef := httptest.NewServer(server)
+	defer clef.Close()
+	clefSigner, err := NewClefSigner(context.Background(), clef.URL, addr)
+	require.NoError(t, err)
+
+	to := common.HexToAddress("0x01")
+	txs := map[string]*gethtypes.Transaction{
+		"legacy":     gethtypes.NewTx(&gethtypes.LegacyTx{Nonce: 1, GasPrice: big.NewInt(2), Gas: 21000, To: &to, Value: big.NewInt(3)}),
+		"accessList": gethtypes.NewTx(&gethtypes.AccessListTx{ChainID: chainID, Nonce: 1, GasPrice: big.NewInt(2), Gas: 21000, To: &to, AccessList: gethtypes.AccessList{{Address: to, StorageKeys: []common.Hash{{}}}}}),
+		"dynamicFee": gethtypes.NewTx(&gethtypes.DynamicFeeTx{ChainID: chainID, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 100000, Data: []byte{0x01, 0x02}}),
+	}
+	signers := map[string]Signer{
+		"web3signer": NewWeb3Signer(web3signer.URL, "key1", addr),
+		"clef":       clefSigner,
+	}
+	for signerName, signer := range signers {
+		for txName, tx := range txs {
+			// 1. The signed transaction is returned to the Signer hook of bind.TransactOpts
+			signed, err := SignerFn(context.Background(), signer, chainID)(addr, tx)
+			require.NoError(t, err, signerName, txName)
+			sender, err := gethtypes.Sender(gethtypes.LatestSignerForChainID(chainID), signed)
+			require.NoError(t, err)
+			require.Equal(t, addr, sender, signerName, txName)
+			require.Equal(t, gethtypes.LatestSignerForChainID(chainID).Hash(tx), gethtypes.LatestSignerForChainID(chainID).Hash(signed))
+		}
+		// 2. Other accounts are not authorized
+		_, err := SignerFn(context.Background(), signer, chainID)(common.HexToAddress("0x02"), txs["legacy"])
+		require.Error(t, err)
+	}
+
+	// 3. The signature by an unexpected key is rejected
+	_, err = NewWeb3Signer(web3signer.URL, "other", addr).SignTx(context.Background(), txs["legacy"], chainID)
+	require.Error(t, err)
+	// 4. The errors of the remote signer are returned
+	_, err = NewWeb3Signer(web3signer.URL, "unknown", addr).SignTx(context.Background(), txs["legacy"], chainID)
+	require.Error(t, err)
+	_, err = NewClefSignerFromRPCClient(rpc.DialInProc(server), common.HexToAddress("0x02")).SignTx(context.Background(), txs["legacy"], chainID)
+	require.Error(t, err)
+}
+
+func TestSigningPayload(t *testing.T) {
+	chainID := big.NewInt(2018)
+	for _, tx := range []*gethtypes.Transaction{
+		gethtypes.NewTx(&gethtypes.LegacyTx{Nonce: 1, GasPrice: big.NewInt(2), Gas: 21000}),
+		gethtypes.NewTx(&gethtypes.AccessListTx{ChainID: chainID, Nonce: 1, GasPrice: big.NewInt(2), Gas: 21000}),
+		gethtypes.NewTx(&gethtypes.DynamicFeeTx{ChainID: chainID, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000}),
+	} {
+		payload, err := signingPayload(tx, chainID)
+		require.NoError(t, err)
+		require.Equal(t, gethtypes.LatestSignerForChainID(chainID).Hash(tx), crypto.Keccak256Hash(payload))
+	}
+}
+
+// testWeb3SignerHandler is a stand-in of the eth1 signing API of Web3Signer
+func testWeb3SignerHandler(keys map[string]*ecdsa.PrivateKey) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/eth1/sign/", func(w http.ResponseWriter, r *http.Request) {
+		key, ok := keys[r.URL.Path[len("/api/v1/eth1/sign/"):]]
+		if !ok {
+			http.Error(w, "key not found", http.StatusNotFound)
+			return
+		}
+		var req struct {
+			Data hexutil.Bytes `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sig, err := crypto.Sign(crypto.Keccak256(req.Data), key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sig[crypto.RecoveryIDOffset] += 27
+		fmt.Fprint(w, hexutil.Encode(sig))
+	})
+	return mux
+}
+
+// testClefService is a stand-in of `account_signTransaction` of Clef
+type testClefService struct {
+	keys map[common.Address]*ecdsa.PrivateKey
+}
+
+func (s *testClefService) SignTransaction(ctx context.Context, args clefTxArgs) (*clefSignResult, error) {
+	key, ok := s.keys[args.From.Address()]
+	if !ok {
+		return nil, fmt.Errorf("account not found: %v", args.From.Address())
+	}
+	var to *common.Address
+	if args.To != nil {
+		addr := args.To.Address()
+		to = &addr
+	}
+	var data []byte
+	if args.Data != nil {
+		data = *args.Data
+	}
+	var accessList gethtypes.AccessList
+	if args.AccessList != nil {
+		accessList = *args.AccessList
+	}
+	chainID := args.ChainID.ToInt()
+	var tx *gethtypes.Transaction
+	switch {
+	case args.MaxFeePerGas != nil:
+		tx = gethtypes.NewTx(&gethtypes.DynamicFeeTx{ChainID: chainID, Nonce: uint64(args.Nonce), GasTipCap: args.MaxPriorityFeePerGas.ToInt(), GasFeeCap: args.MaxFeePerGas.ToInt(), Gas: uint64(args.Gas), To: to, Value: args.Value.ToInt(), Data: data, AccessList: accessList})
+	case args.AccessList != nil:
+		tx = gethtypes.NewTx(&gethtypes.AccessListTx{ChainID: chainID, Nonce: uint64(args.Nonce), GasPrice: args.GasPrice.ToInt(), Gas: uint64(args.Gas), To: to, Value: args.Value.ToInt(), Data: data, AccessList: accessList})
+	default:
+		tx = gethtypes.NewTx(&gethtypes.LegacyTx{Nonce: uint64(args.Nonce), GasPrice: args.GasPrice.ToInt(), Gas: uint64(args.Gas), To: to, Value: args.Value.ToInt(), Data: data})
+	}
+	signed, err := gethtypes.SignTx(tx, gethtypes.LatestSignerForChainID(chainID), key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &clefSignResult{Raw: raw}, nil
+}
@@ -0,0 +1,165 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer signs transactions of an account without exposing its private key
+type Signer interface {
+	// Address returns the address of the account
+	Address() common.Address
+	// SignTx returns the transaction signed for the chain
+	SignTx(ctx context.Context, tx *gethtypes.Transaction, chainID *big.Int) (*gethtypes.Transaction, error)
+}
+
+// Keyring provides the signers of the accounts identified by the index
+type Keyring interface {
+	Signer(index uint32) (Signer, error)
+}
+
+// SignerFn returns a bind.SignerFn that signs transactions with the signer
+func SignerFn(ctx context.Context, signer Signer, chainID *big.Int) bind.SignerFn {
+	addr := signer.Address()
+	return func(address common.Address, tx *gethtypes.Transaction) (*gethtypes.Transaction, error) {
+		if address != addr {
+			return nil, bind.ErrNotAuthorized
+		}
+		return signer.SignTx(ctx, tx, chainID)
+	}
+}
+
+// keySigner is a Signer that holds the private key in memory
+type keySigner struct {
+	key  *ecdsa.PrivateKey
+	addr common.Address
+}
+
+var _ Signer = (*keySigner)(nil)
+
+func newKeySigner(key *ecdsa.PrivateKey) *keySigner {
+	return &keySigner{key: key, addr: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+func (s *keySigner) Address() common.Address {
+	return s.addr
+}
+
+func (s *keySigner) SignTx(ctx context.Context, tx *gethtypes.Transaction, chainID *big.Int) (*gethtypes.Transaction, error) {
+	return gethtypes.SignTx(tx, gethtypes.LatestSignerForChainID(chainID), s.key)
+}
+
+// NewMnemonicSigner returns a Signer of the key derived from the mnemonic with the HD path(e.g. "m/44'/60'/0'/0/0")
+func NewMnemonicSigner(mnemonic, path string) (Signer, error) {
+	return NewMnemonicSignerWithPassphrase(mnemonic, "", path)
+}
+
+// NewMnemonicSignerWithPassphrase returns a Signer of the key derived from the mnemonic and the BIP39 passphrase with the HD path
+func NewMnemonicSignerWithPassphrase(mnemonic, passphrase, path string) (Signer, error) {
+	key, err := GetPrvKeyFromMnemonic(mnemonic, passphrase, path)
+	if err != nil {
+		return nil, err
+	}
+	return newKeySigner(key), nil
+}
+
+// NewKeystoreSigner returns a Signer of the key in a geth keystore JSON encrypted with the passphrase
+func NewKeystoreSigner(keyJSON []byte, passphrase string) (Signer, error) {
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return newKeySigner(key.PrivateKey), nil
+}
+
+// NewKeystoreFileSigner returns a Signer of the key in a geth keystore file encrypted with the passphrase
+func NewKeystoreFileSigner(path, passphrase string) (Signer, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := NewKeystoreSigner(bz, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the keystore: path=%v err=%v", path, err)
+	}
+	return signer, nil
+}
+
+// NewRawKeySigner returns a Signer of the hex encoded private key. The "0x" prefix is optional.
+func NewRawKeySigner(hexKey string) (Signer, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil {
+		return nil, err
+	}
+	return newKeySigner(key), nil
+}
+
+// DefaultHDPathTemplate is the path template of the accounts derived by NewMnemonicKeyring
+const DefaultHDPathTemplate = "m/44'/60'/0'/0/" + IndexPlaceholder
+
+// mnemonicKeyring derives the signer of the index from the mnemonic with the path template
+type mnemonicKeyring struct {
+	mnemonic   string
+	passphrase string
+	template   string
+
+	mu      sync.Mutex
+	signers map[uint32]Signer
+}
+
+var _ Keyring = (*mnemonicKeyring)(nil)
+
+// NewMnemonicKeyring returns a Keyring that derives the signer of the index from the mnemonic with the path "m/44'/60'/0'/0/{index}"
+func NewMnemonicKeyring(mnemonic string) Keyring {
+	return &mnemonicKeyring{mnemonic: mnemonic, template: DefaultHDPathTemplate, signers: make(map[uint32]Signer)}
+}
+
+// NewMnemonicKeyringWithPath returns a Keyring that derives the signer of the index from the mnemonic and the BIP39 passphrase.
+// The path of the index is made from the template(e.g. "m/44'/60'/{index}'/0/0"). See PathFromTemplate.
+func NewMnemonicKeyringWithPath(mnemonic, passphrase, template string) (Keyring, error) {
+	if _, err := PathFromTemplate(template, 0); err != nil {
+		return nil, err
+	}
+	return &mnemonicKeyring{mnemonic: mnemonic, passphrase: passphrase, template: template, signers: make(map[uint32]Signer)}, nil
+}
+
+func (k *mnemonicKeyring) Signer(index uint32) (Signer, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if signer, ok := k.signers[index]; ok {
+		return signer, nil
+	}
+	path, err := PathFromTemplate(k.template, index)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := NewMnemonicSignerWithPassphrase(k.mnemonic, k.passphrase, path)
+	if err != nil {
+		return nil, err
+	}
+	k.signers[index] = signer
+	return signer, nil
+}
+
+// StaticKeyring is a Keyring of the fixed signers. The index is the position in the list.
+type StaticKeyring []Signer
+
+var _ Keyring = (StaticKeyring)(nil)
+
+func (k StaticKeyring) Signer(index uint32) (Signer, error) {
+	if int(index) >= len(k) {
+		return nil, fmt.Errorf("signer not found: index=%v size=%v", index, len(k))
+	}
+	return k[index], nil
+}
@@ -0,0 +1,57 @@
+package wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "math razor capable expose worth grape metal sunset metal sudden usage scheme"
+
+func TestSigners(t *testing.T) {
+	key, err := GetPrvKeyFromMnemonicAndHDWPath(testMnemonic, "m/44'/60'/0'/0/1")
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	account, err := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP).ImportECDSA(key, "passphrase")
+	require.NoError(t, err)
+	keyFile := account.URL.Path
+
+	mnemonicSigner, err := NewMnemonicSigner(testMnemonic, "m/44'/60'/0'/0/1")
+	require.NoError(t, err)
+	keyringSigner, err := NewMnemonicKeyring(testMnemonic).Signer(1)
+	require.NoError(t, err)
+	keystoreSigner, err := NewKeystoreFileSigner(keyFile, "passphrase")
+	require.NoError(t, err)
+	rawSigner, err := NewRawKeySigner("0x" + hex.EncodeToString(crypto.FromECDSA(key)))
+	require.NoError(t, err)
+
+	chainID := big.NewInt(2018)
+	tx := gethtypes.NewTx(&gethtypes.DynamicFeeTx{ChainID: chainID, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000})
+	for name, signer := range map[string]Signer{
+		"mnemonic": mnemonicSigner,
+		"keyring":  keyringSigner,
+		"keystore": keystoreSigner,
+		"raw":      rawSigner,
+	} {
+		require.Equal(t, addr, signer.Address(), name)
+		signed, err := signer.SignTx(context.Background(), tx, chainID)
+		require.NoError(t, err, name)
+		sender, err := gethtypes.Sender(gethtypes.LatestSignerForChainID(chainID), signed)
+		require.NoError(t, err, name)
+		require.Equal(t, addr, sender, name)
+	}
+
+	_, err = NewKeystoreFileSigner(keyFile, "wrong")
+	require.Error(t, err)
+	_, err = NewRawKeySigner("0x01")
+	require.Error(t, err)
+	_, err = StaticKeyring{rawSigner}.Signer(1)
+	require.Error(t, err)
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	bip39 "github.com/tyler-smith/go-bip39"
 )
 
@@ -89,52 +91,141 @@ func (hp *HDPathLevel) String() string {
 	return fmt.Sprintf("m/%v'/%v'/%v'/%v/%v", hp.Purpose, hp.CoinType, hp.Account, hp.Change, hp.Index)
 }
 
-func GetPrvKeyFromHDWallet(seed []byte, hp *HDPathLevel) (*ecdsa.PrivateKey, error) {
-	// Generate a new master node using the seed.
-	masterKey, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
-	if err != nil {
-		return nil, err
+// HDPath returns the path of the levels
+func (hp *HDPathLevel) HDPath() HDPath {
+	return HDPath{
+		hdkeychain.HardenedKeyStart + hp.Purpose,
+		hdkeychain.HardenedKeyStart + hp.CoinType,
+		hdkeychain.HardenedKeyStart + hp.Account,
+		hp.Change,
+		hp.Index,
 	}
-	// This gives the path: m/{purpose}'
-	acc, err := masterKey.Derive(hdkeychain.HardenedKeyStart + hp.Purpose)
-	if err != nil {
-		return nil, err
+}
+
+// HDPath is a BIP32 derivation path. Each element is a child index and an index not less than 2^31 is hardened.
+type HDPath []uint32
+
+// ParseHDPath parses a BIP32 path of arbitrary depth like "m/84'/0'/0'/0/1" or "m/44'/60'/1'/0/0".
+// Any level can be hardened with the suffix "'", "h" or "H".
+func ParseHDPath(path string) (HDPath, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("prefix should be 'm'")
 	}
-	// This gives the path: m/{purpose}'/{coin_type}'
-	acc, err = acc.Derive(hdkeychain.HardenedKeyStart + hp.CoinType)
-	if err != nil {
-		return nil, err
+	var hp HDPath
+	for _, part := range parts[1:] {
+		hardened := false
+		if n := len(part); n > 0 && (part[n-1] == '\'' || part[n-1] == 'h' || part[n-1] == 'H') {
+			hardened = true
+			part = part[:n-1]
+		}
+		v, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path level: %v", part)
+		} else if v >= hdkeychain.HardenedKeyStart {
+			return nil, fmt.Errorf("path level must be less than 2^31: %v", v)
+		}
+		if hardened {
+			v += hdkeychain.HardenedKeyStart
+		}
+		hp = append(hp, uint32(v))
 	}
-	// This gives the path: m/{purpose}'/{coin_type}'/{account}'
-	acc, err = acc.Derive(hdkeychain.HardenedKeyStart + hp.Account)
-	if err != nil {
-		return nil, err
+	return hp, nil
+}
+
+func (hp HDPath) String() string {
+	var sb strings.Builder
+	sb.WriteString("m")
+	for _, v := range hp {
+		if v >= hdkeychain.HardenedKeyStart {
+			fmt.Fprintf(&sb, "/%v'", v-hdkeychain.HardenedKeyStart)
+		} else {
+			fmt.Fprintf(&sb, "/%v", v)
+		}
 	}
-	// This gives the path: m/{purpose}'/{coin_type}'/{account}'/{change}
-	acc, err = acc.Derive(0 + hp.Change)
+	return sb.String()
+}
+
+func GetPrvKeyFromHDWallet(seed []byte, hp *HDPathLevel) (*ecdsa.PrivateKey, error) {
+	return GetPrvKeyFromHDPath(seed, hp.HDPath())
+}
+
+// GetPrvKeyFromHDPath derives the private key of the path from the seed
+func GetPrvKeyFromHDPath(seed []byte, path HDPath) (*ecdsa.PrivateKey, error) {
+	// Generate a new master node using the seed.
+	key, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
 	if err != nil {
 		return nil, err
 	}
-	// This gives the path: m/{purpose}'/{coin_type}'/{account}'/{change}/{index}
-	acc, err = acc.Derive(hp.Index)
-	if err != nil {
-		return nil, err
+	for _, idx := range path {
+		key, err = key.Derive(idx)
+		if err != nil {
+			return nil, err
+		}
 	}
-	btcecPrivKey, err := acc.ECPrivKey()
+	btcecPrivKey, err := key.ECPrivKey()
 	if err != nil {
 		return nil, err
 	}
 	return btcecPrivKey.ToECDSA(), nil
 }
 
+// GetPrvKeyFromMnemonicAndHDWPath derives the private key of the path from the mnemonic without a passphrase
 func GetPrvKeyFromMnemonicAndHDWPath(mnemonic, path string) (*ecdsa.PrivateKey, error) {
+	return GetPrvKeyFromMnemonic(mnemonic, "", path)
+}
+
+// GetPrvKeyFromMnemonic derives the private key of the path from the mnemonic with the BIP39 passphrase
+func GetPrvKeyFromMnemonic(mnemonic, passphrase, path string) (*ecdsa.PrivateKey, error) {
 	if !bip39.IsMnemonicValid(mnemonic) {
 		return nil, errors.New("invalid mnemonic")
 	}
-	hp, err := ParseHDPathLevel(path)
+	hp, err := ParseHDPath(path)
 	if err != nil {
 		return nil, err
 	}
-	seed := bip39.NewSeed(mnemonic, "")
-	return GetPrvKeyFromHDWallet(seed, hp)
+	seed := bip39.NewSeed(mnemonic, passphrase)
+	return GetPrvKeyFromHDPath(seed, hp)
+}
+
+// IndexPlaceholder is replaced with the index in a path template. e.g. "m/44'/60'/{index}'/0/0" is the path of Ledger Live.
+const IndexPlaceholder = "{index}"
+
+// DerivedAccount is an account derived from a mnemonic
+type DerivedAccount struct {
+	Path    string
+	Address common.Address
+}
+
+// PathFromTemplate replaces the placeholder in the template with the index
+func PathFromTemplate(template string, index uint32) (string, error) {
+	if strings.Count(template, IndexPlaceholder) != 1 {
+		return "", fmt.Errorf("template must contain exactly one %v: %v", IndexPlaceholder, template)
+	}
+	return strings.Replace(template, IndexPlaceholder, strconv.FormatUint(uint64(index), 10), 1), nil
+}
+
+// DeriveAccounts derives the accounts of `count` indexes from `start` for the path template
+func DeriveAccounts(mnemonic, passphrase, template string, start, count uint32) ([]DerivedAccount, error) {
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, errors.New("invalid mnemonic")
+	}
+	seed := bip39.NewSeed(mnemonic, passphrase)
+	var accounts []DerivedAccount
+	for i := uint32(0); i < count; i++ {
+		path, err := PathFromTemplate(template, start+i)
+		if err != nil {
+			return nil, err
+		}
+		hp, err := ParseHDPath(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := GetPrvKeyFromHDPath(seed, hp)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, DerivedAccount{Path: path, Address: crypto.PubkeyToAddress(key.PublicKey)})
+	}
+	return accounts, nil
 }
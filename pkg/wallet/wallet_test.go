@@ -0,0 +1,90 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+	bip39 "github.com/tyler-smith/go-bip39"
+)
+
+func TestParseHDPath(t *testing.T) {
+	for _, c := range []struct {
+		path     string
+		expected string
+	}{
+		{"m", "m"},
+		{"m/44'/60'/0'/0/1", "m/44'/60'/0'/0/1"},
+		{"m/84h/0H/0'/0/0", "m/84'/0'/0'/0/0"},
+		{"m/0'/1/2'/2/1000000000", "m/0'/1/2'/2/1000000000"},
+		{"m/44'/60'/3'/0/0", "m/44'/60'/3'/0/0"},
+	} {
+		hp, err := ParseHDPath(c.path)
+		require.NoError(t, err, c.path)
+		require.Equal(t, c.expected, hp.String())
+	}
+	for _, path := range []string{"", "44'/60'", "m/", "m/-1", "m/2147483648", "m/1''", "m/x"} {
+		_, err := ParseHDPath(path)
+		require.Error(t, err, path)
+	}
+
+	// the strict BIP44 path has the same derivation path
+	level, err := ParseHDPathLevel("m/44'/60'/1'/0/2")
+	require.NoError(t, err)
+	hp, err := ParseHDPath("m/44'/60'/1'/0/2")
+	require.NoError(t, err)
+	require.Equal(t, hp, level.HDPath())
+}
+
+func TestGetPrvKeyFromHDPath(t *testing.T) {
+	// test vector 1 of BIP32
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	require.NoError(t, err)
+	hp, err := ParseHDPath("m/0'/1/2'/2/1000000000")
+	require.NoError(t, err)
+	key, err := GetPrvKeyFromHDPath(seed, hp)
+	require.NoError(t, err)
+	require.Equal(t, "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8", hex.EncodeToString(crypto.FromECDSA(key)))
+}
+
+func TestGetPrvKeyFromMnemonic(t *testing.T) {
+	const mnemonic = "test test test test test test test test test test test junk"
+
+	// 1. The well-known accounts of the mnemonic without a passphrase
+	key, err := GetPrvKeyFromMnemonicAndHDWPath(mnemonic, "m/44'/60'/0'/0/0")
+	require.NoError(t, err)
+	require.Equal(t, common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"), crypto.PubkeyToAddress(key.PublicKey))
+	accounts, err := DeriveAccounts(mnemonic, "", DefaultHDPathTemplate, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []DerivedAccount{
+		{Path: "m/44'/60'/0'/0/1", Address: common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")},
+		{Path: "m/44'/60'/0'/0/2", Address: common.HexToAddress("0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC")},
+	}, accounts)
+
+	// 2. The passphrase changes the seed
+	key, err = GetPrvKeyFromMnemonic(mnemonic, "passphrase", "m/44'/60'/0'/0/0")
+	require.NoError(t, err)
+	hp, err := ParseHDPath("m/44'/60'/0'/0/0")
+	require.NoError(t, err)
+	expected, err := GetPrvKeyFromHDPath(bip39.NewSeed(mnemonic, "passphrase"), hp)
+	require.NoError(t, err)
+	require.Equal(t, expected, key)
+	require.NotEqual(t, common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"), crypto.PubkeyToAddress(key.PublicKey))
+
+	// 3. The index can be placed at any level like Ledger Live
+	accounts, err = DeriveAccounts(mnemonic, "", "m/44'/60'/{index}'/0/0", 0, 2)
+	require.NoError(t, err)
+	require.Equal(t, "m/44'/60'/1'/0/0", accounts[1].Path)
+	keyring, err := NewMnemonicKeyringWithPath(mnemonic, "", "m/44'/60'/{index}'/0/0")
+	require.NoError(t, err)
+	signer, err := keyring.Signer(1)
+	require.NoError(t, err)
+	require.Equal(t, accounts[1].Address, signer.Address())
+
+	_, err = DeriveAccounts(mnemonic, "", "m/44'/60'/0'/0/0", 0, 1)
+	require.Error(t, err)
+	_, err = NewMnemonicKeyringWithPath(mnemonic, "", "m/{index}/{index}")
+	require.Error(t, err)
+}